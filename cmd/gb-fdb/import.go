@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// The maximum number of rows inserted by a single INSERT statement.
+// The actual number may be lower for wide tables, since the number
+// of placeholders per statement is limited by each database.
+const insertBatchSize = 1000
+
+// Implemented by each [Converter] to import the tables
+// of an FDB file with [importFdb].
+type tableImporter interface {
+	// Returns the names of the tables within the database.
+	tableNames() ([]string, error)
+
+	countRows(tableName string) (int, error)
+
+	importTable(table *fdb.Table, options importOptions) error
+}
+
+type importOptions struct {
+	// Drop the existing table before importing.
+	Drop bool
+
+	// Create the table and its indexes. If false,
+	// rows are appended to the existing table.
+	Create bool
+
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+func countRows(db *sql.DB, tableName string, quote quoteFunc) (int, error) {
+	query := fmt.Sprint("SELECT COUNT(*) FROM ", quote(tableName))
+	Verbose.Println(query)
+
+	var count int
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func countFdbRows(table *fdb.Table) (int, error) {
+	count := 0
+	for _, err := range table.Rows() {
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Imports the tables of r one table at a time, taking [ImportTables],
+// [KeepTables], and [ResumeImport] into account. Indexes are only
+// created along with their table.
+func importFdb(imp tableImporter, r *fdb.Reader) error {
+	for _, name := range ImportTables {
+		if _, ok := r.FindTable(name); !ok {
+			return fmt.Errorf("import: unknown table: %s", name)
+		}
+	}
+
+	if err := checkIndexes(r); err != nil {
+		return fmt.Errorf("import: %v", err)
+	}
+
+	names, err := imp.tableNames()
+	if err != nil {
+		return fmt.Errorf("import: %v", err)
+	}
+
+	for _, table := range r.Tables() {
+		if len(ImportTables) > 0 && !slices.Contains(ImportTables, table.Name) {
+			Verbose.Print("skipping table \"", table.Name, "\"")
+			continue
+		}
+
+		options := importOptions{Create: true}
+		if slices.Contains(names, table.Name) {
+			switch {
+			case ResumeImport:
+				expected, err := countFdbRows(table)
+				if err != nil {
+					return fmt.Errorf("import %s: %v", table.Name, err)
+				}
+
+				actual, err := imp.countRows(table.Name)
+				if err != nil {
+					return fmt.Errorf("import %s: %v", table.Name, err)
+				}
+
+				if actual == expected {
+					Verbose.Print(table.Name, ": already complete")
+					continue
+				}
+
+				Verbose.Printf("%s: incomplete (%d of %d rows)", table.Name, actual, expected)
+				options.Drop = true
+			case KeepTables:
+				options.Create = false
+			default:
+				options.Drop = true
+			}
+		}
+
+		if options.Create {
+			options.ForeignKeys = tableForeignKeys(r, table)
+			for _, fk := range options.ForeignKeys {
+				Verbose.Print("foreign key: ", fk)
+			}
+
+			options.Indexes = tableIndexes(table, options.ForeignKeys)
+		}
+
+		start := time.Now()
+		if err := imp.importTable(table, options); err != nil {
+			return fmt.Errorf("import %s: %v", table.Name, err)
+		}
+		Verbose.Printf("%s: imported in %v", table.Name, time.Since(start))
+	}
+
+	return nil
+}
+
+func insertQuery(table *fdb.Table, numRows int, quote quoteFunc) string {
+	colNames := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		colNames[i] = quote(col.Name)
+	}
+
+	row := "(" + strings.Repeat(",?", len(table.Columns))[1:] + ")"
+	return fmt.Sprint("INSERT INTO ", quote(table.Name), " (", strings.Join(colNames, ","), ") VALUES ", strings.Repeat(","+row, numRows)[1:])
+}
+
+// Inserts every row of the table using prepared multi-row INSERT
+// statements. maxParams is the maximum number of placeholders
+// allowed within a single statement.
+func insertRows(tx *sql.Tx, table *fdb.Table, quote quoteFunc, maxParams int, rowValues func(fdb.Row, []any) error) error {
+	if len(table.Columns) == 0 {
+		return nil
+	}
+	batchSize := min(insertBatchSize, max(1, maxParams/len(table.Columns)))
+
+	batchQuery := insertQuery(table, batchSize, quote)
+	Verbose.Print(table.Name, ": inserting ", batchSize, " rows per statement")
+
+	stmt, err := tx.Prepare(batchQuery)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]any, batchSize*len(table.Columns))
+	numRows := 0
+	for row, err := range table.Rows() {
+		if err != nil {
+			return err
+		}
+
+		offset := numRows * len(table.Columns)
+		if err := rowValues(row, values[offset:offset+len(table.Columns)]); err != nil {
+			return err
+		}
+		numRows++
+
+		if numRows == batchSize {
+			if _, err := stmt.Exec(values...); err != nil {
+				return err
+			}
+			numRows = 0
+		}
+	}
+
+	if numRows > 0 {
+		if _, err := tx.Exec(insertQuery(table, numRows, quote), values[:numRows*len(table.Columns)]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func createIndexes(exec func(query string, args ...any) (sql.Result, error), table *fdb.Table, indexes []Index, quote quoteFunc, prefixLength int) error {
+	for _, index := range indexes {
+		query := indexQuery(table, index, quote, prefixLength)
+		Verbose.Println(query)
+
+		if _, err := exec(query); err != nil {
+			return fmt.Errorf("index %s: %v", index.name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+type Index struct {
+	// Defaults to "<table>_<column>[_<column>...]_idx" if empty.
+	Name    string   `json:"name,omitempty"`
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+func (i Index) name() string {
+	if len(i.Name) > 0 {
+		return i.Name
+	}
+	return fmt.Sprint(i.Table, "_", strings.Join(i.Columns, "_"), "_idx")
+}
+
+// A column which references the column of another table.
+type ForeignKey struct {
+	Table     string `json:"table"`
+	Column    string `json:"column"`
+	RefTable  string `json:"refTable"`
+	RefColumn string `json:"refColumn"`
+}
+
+func (fk ForeignKey) String() string {
+	return fmt.Sprint(fk.Table, ".", fk.Column, " -> ", fk.RefTable, ".", fk.RefColumn)
+}
+
+// Extra indexes and foreign keys created when converting
+// from FDB, as read from the file provided with -indexes.
+type IndexSpec struct {
+	Indexes     []Index      `json:"indexes"`
+	ForeignKeys []ForeignKey `json:"foreignKeys"`
+}
+
+// Well-known relationships between cdclient tables.
+var cdclientForeignKeys = []ForeignKey{
+	{"ComponentsRegistry", "id", "Objects", "id"},
+	{"ObjectSkills", "objectTemplate", "Objects", "id"},
+	{"ObjectSkills", "skillID", "SkillBehavior", "skillID"},
+	{"SkillBehavior", "behaviorID", "BehaviorTemplate", "behaviorID"},
+	{"BehaviorParameter", "behaviorID", "BehaviorTemplate", "behaviorID"},
+	{"MissionTasks", "id", "Missions", "id"},
+	{"LootTable", "itemid", "Objects", "id"},
+	{"LootTable", "LootTableIndex", "LootTableIndex", "LootTableIndex"},
+	{"LootMatrix", "LootTableIndex", "LootTableIndex", "LootTableIndex"},
+}
+
+func readIndexSpec(name string) *IndexSpec {
+	file, err := os.Open(name)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer file.Close()
+
+	spec := &IndexSpec{}
+	if err := json.NewDecoder(file).Decode(spec); err != nil {
+		Error.Fatalf("read index spec: %v", err)
+	}
+
+	return spec
+}
+
+func hasColumn(table *fdb.Table, name string) bool {
+	return slices.ContainsFunc(table.Columns, func(c *fdb.Column) bool { return c.Name == name })
+}
+
+// Checks that every index within [Indexes] refers
+// to a table and columns within the FDB file.
+func checkIndexes(r *fdb.Reader) error {
+	for _, index := range Indexes.Indexes {
+		table, ok := r.FindTable(index.Table)
+		if !ok {
+			return fmt.Errorf("index %s: unknown table: %s", index.name(), index.Table)
+		}
+
+		if len(index.Columns) == 0 {
+			return fmt.Errorf("index %s: no columns", index.name())
+		}
+
+		for _, column := range index.Columns {
+			if !hasColumn(table, column) {
+				return fmt.Errorf("index %s: unknown column: %s", index.name(), column)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the foreign keys of the table whose columns exist within
+// the FDB file, from both [cdclientForeignKeys] and [Indexes].
+func tableForeignKeys(r *fdb.Reader, table *fdb.Table) []ForeignKey {
+	foreignKeys := []ForeignKey{}
+	for _, fk := range slices.Concat(cdclientForeignKeys, Indexes.ForeignKeys) {
+		if fk.Table != table.Name || !hasColumn(table, fk.Column) {
+			continue
+		}
+
+		refTable, ok := r.FindTable(fk.RefTable)
+		if !ok || !hasColumn(refTable, fk.RefColumn) {
+			continue
+		}
+
+		if !slices.Contains(foreignKeys, fk) {
+			foreignKeys = append(foreignKeys, fk)
+		}
+	}
+	return foreignKeys
+}
+
+// Returns the indexes to create for the table: one on the first
+// (hash key) column, one for each of the table's foreign keys, and
+// the table's indexes from [Indexes]. See [checkIndexes].
+func tableIndexes(table *fdb.Table, foreignKeys []ForeignKey) []Index {
+	indexes := []Index{}
+	add := func(index Index) {
+		if !slices.ContainsFunc(indexes, func(i Index) bool { return slices.Equal(i.Columns, index.Columns) }) {
+			indexes = append(indexes, index)
+		}
+	}
+
+	// Indexes from the spec come first so that they take
+	// priority over generated indexes on the same columns.
+	for _, index := range Indexes.Indexes {
+		if index.Table == table.Name {
+			add(index)
+		}
+	}
+
+	if len(table.Columns) > 0 {
+		add(Index{Table: table.Name, Columns: []string{table.Columns[0].Name}})
+	}
+
+	for _, fk := range foreignKeys {
+		add(Index{Table: table.Name, Columns: []string{fk.Column}})
+	}
+
+	return indexes
+}
+
+// Returns a CREATE INDEX query. If prefixLength is greater than
+// 0, text columns are indexed by their first prefixLength characters.
+func indexQuery(table *fdb.Table, index Index, quote quoteFunc, prefixLength int) string {
+	columns := make([]string, len(index.Columns))
+	for i, name := range index.Columns {
+		columns[i] = quote(name)
+
+		j := slices.IndexFunc(table.Columns, func(c *fdb.Column) bool { return c.Name == name })
+		if j < 0 || prefixLength <= 0 {
+			continue
+		}
+
+		switch table.Columns[j].Variant {
+		case fdb.VariantNVarChar, fdb.VariantText, fdb.VariantNull:
+			columns[i] += fmt.Sprintf("(%d)", prefixLength)
+		}
+	}
+
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprint("CREATE ", unique, "INDEX ", quote(index.name()), " ON ", quote(table.Name), " (", strings.Join(columns, ","), ")")
+}
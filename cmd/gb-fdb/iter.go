@@ -9,18 +9,38 @@ import (
 	"github.com/I-Am-Dench/goverbuild/database/fdb"
 )
 
-func queryRows(db *sql.DB, table *fdb.Table) (*sql.Rows, error) {
+// Quotes a table or column name for use within a query.
+type quoteFunc = func(name string) string
+
+func quoteDouble(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteBacktick(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Returns the query which selects the table's rows. Ordered rows are
+// sorted by every column, starting with the first, so that only rows
+// with identical values can be returned in a different order.
+func selectQuery(table *fdb.Table, quote quoteFunc, ordered bool) string {
 	colNames := strings.Builder{}
 	for i, col := range table.Columns {
 		if i > 0 {
 			colNames.WriteRune(',')
 		}
-		colNames.WriteRune('"')
-		colNames.WriteString(col.Name)
-		colNames.WriteRune('"')
+		colNames.WriteString(quote(col.Name))
+	}
+
+	query := fmt.Sprint("SELECT ", colNames.String(), " FROM ", quote(table.Name))
+	if ordered && len(table.Columns) > 0 {
+		query += " ORDER BY " + colNames.String()
 	}
+	return query
+}
 
-	query := fmt.Sprint("SELECT ", colNames.String(), " FROM ", table.Name)
+func queryRows(db *sql.DB, table *fdb.Table, quote quoteFunc, ordered bool) (*sql.Rows, error) {
+	query := selectQuery(table, quote, ordered)
 	Verbose.Println(query)
 	return db.Query(query)
 }
@@ -43,13 +63,14 @@ func scanRow(rows *sql.Rows, columns []*fdb.Column) (fdb.Row, error) {
 	return row, nil
 }
 
-func iterRows(db *sql.DB, table *fdb.Table) iter.Seq2[fdb.Row, error] {
+func iterRows(db *sql.DB, table *fdb.Table, quote quoteFunc, ordered bool) iter.Seq2[fdb.Row, error] {
 	return func(yield func(fdb.Row, error) bool) {
-		rows, err := queryRows(db, table)
+		rows, err := queryRows(db, table, quote, ordered)
 		if err != nil {
 			yield(nil, err)
 			return
 		}
+		defer rows.Close()
 
 		for rows.Next() {
 			row, err := scanRow(rows, table.Columns)
@@ -69,8 +90,8 @@ func iterRows(db *sql.DB, table *fdb.Table) iter.Seq2[fdb.Row, error] {
 	}
 }
 
-func IterTables(db *sql.DB, tables map[string]*fdb.Table) fdb.RowsFunc {
+func IterTables(db *sql.DB, tables map[string]*fdb.Table, quote quoteFunc, ordered bool) fdb.RowsFunc {
 	return func(tableName string) iter.Seq2[fdb.Row, error] {
-		return iterRows(db, tables[tableName])
+		return iterRows(db, tables[tableName], quote, ordered)
 	}
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func TestSelectQuery(t *testing.T) {
+	table := &fdb.Table{Name: "Items", Columns: []*fdb.Column{{Variant: fdb.VariantI32, Name: "id"}, {Variant: fdb.VariantNVarChar, Name: "name"}}}
+
+	if query := selectQuery(table, quoteBacktick, false); query != "SELECT `id`,`name` FROM `Items`" {
+		t.Errorf("unexpected query: %s", query)
+	}
+
+	if query := selectQuery(table, quoteDouble, true); query != `SELECT "id","name" FROM "Items" ORDER BY "id","name"` {
+		t.Errorf("unexpected query: %s", query)
+	}
+}
+
+func TestOrderRows(t *testing.T) {
+	// Rows with duplicate keys are inserted in opposite orders.
+	values := []string{}
+	for i := range 100 {
+		values = append(values, fmt.Sprintf("(%d, 'row%d')", i/2, i))
+	}
+
+	build := func(name string, values []string) []byte {
+		db, converter := openSqlite(t)
+		if _, err := db.Exec(`CREATE TABLE "Items" ("id" INT32, "name" TEXT4)`); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, value := range values {
+			if _, err := db.Exec(`INSERT INTO "Items" VALUES ` + value); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		name = filepath.Join(t.TempDir(), name)
+		file, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := converter.WriteFdb(file, TableOptions{OrderRows: true}); err != nil {
+			file.Close()
+			t.Fatal(err)
+		}
+		file.Close()
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	reversed := make([]string, len(values))
+	for i, value := range values {
+		reversed[len(values)-1-i] = value
+	}
+
+	if !bytes.Equal(build("a.fdb", values), build("b.fdb", reversed)) {
+		t.Error("expected identical files")
+	}
+}
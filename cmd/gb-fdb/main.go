@@ -7,14 +7,37 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/I-Am-Dench/goverbuild/database/fdb"
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var (
-	VerboseFlag  bool
-	ExcludeTable string
+	VerboseFlag     bool
+	ExcludeTable    string
+	MaxBufferedRows int
+	SchemaFile      string
+	ImportTables    []string
+	KeepTables      bool
+	ResumeImport    bool
+	KeepColumnOrder bool
+	SortBuckets     bool
+	Deterministic   bool
+
+	// The number of buckets of each table's hash table
+	// when converting to FDB. See [fdb.BuilderOptions].
+	NumBuckets = map[string]int{}
+
+	// Column variants which override the variants
+	// guessed from the database's column types.
+	ForcedSchema = &fdb.Schema{}
+
+	// Extra indexes and foreign keys to create
+	// when converting from FDB.
+	Indexes = &IndexSpec{}
 )
 
 type verboseWriter struct{}
@@ -37,17 +60,60 @@ type Exclude struct {
 	Columns []string
 }
 
+// Reads an exclude table, where each row contains a table name
+// and either a column name or "*" to exclude the entire table.
+func queryExcludes(db *sql.DB, query string) (map[string]*Exclude, error) {
+	excludes := make(map[string]*Exclude)
+
+	Verbose.Println(query)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, column string
+		if err := rows.Scan(&name, &column); err != nil {
+			return nil, err
+		}
+
+		exclude, ok := excludes[name]
+		if !ok {
+			exclude = &Exclude{}
+			excludes[name] = exclude
+		}
+
+		if column == "*" {
+			exclude.All = true
+		} else {
+			exclude.Columns = append(exclude.Columns, column)
+		}
+	}
+
+	return excludes, rows.Err()
+}
+
 type Converter interface {
-	WriteFdb(w io.WriteSeeker, exclude map[string]*Exclude) error
+	Query(query string, args ...any) (*sql.Rows, error)
+
+	Tables(options TableOptions) ([]*fdb.Table, fdb.RowsFunc, error)
+	WriteFdb(w io.WriteSeeker, options TableOptions) error
 	ReadFdb(*fdb.Reader) error
 	GetExcludeTable(tableName string) (map[string]*Exclude, error)
+
+	// Quotes a table or column name.
+	quote(name string) string
 }
 
 var DriverName string
 
 const Usage = `Usage:
 	gb-fdb toFdb [options] <database DSN> [output file]
-	gb-fdb fromFdb [options] <database DSN> [input file]`
+	gb-fdb fromFdb [options] <database DSN> [input file]
+	gb-fdb validate -schema <schema file> [options] <database DSN>
+	gb-fdb verify [options] <fdb file> <database DSN>`
 
 func usage(flagset *flag.FlagSet) func() {
 	return func() {
@@ -58,7 +124,9 @@ func usage(flagset *flag.FlagSet) func() {
 }
 
 var Converters = map[string]func(db *sql.DB) Converter{
-	"sqlite3": NewSqlite,
+	"sqlite3":  NewSqlite,
+	"postgres": NewPostgres,
+	"mysql":    NewMysql,
 }
 
 func GetConverter(driverName, dsn string) (Converter, error) {
@@ -75,11 +143,71 @@ func GetConverter(driverName, dsn string) (Converter, error) {
 	return converter(db), nil
 }
 
+func builderOptions() fdb.BuilderOptions {
+	return fdb.BuilderOptions{
+		MaxBufferedRows: MaxBufferedRows,
+		SortBuckets:     SortBuckets,
+		NumBuckets: func(table *fdb.Table, numKeys int) int {
+			return NumBuckets[table.Name]
+		},
+	}
+}
+
+// Parses a comma separated list of <table>=<# of buckets>.
+func parseNumBuckets(s string) (map[string]int, error) {
+	numBuckets := map[string]int{}
+	for _, pair := range strings.Split(s, ",") {
+		name, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid bucket count: %s", pair)
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid bucket count: %s", pair)
+		}
+
+		numBuckets[name] = n
+	}
+	return numBuckets, nil
+}
+
+func logBuildStats(stats fdb.BuildStats) {
+	Verbose.Printf("wrote %d bytes: %d of %d strings and %d of %d 64-bit values were unique, saving %d bytes",
+		stats.Size, stats.UniqueStrings, stats.Strings, stats.UniqueInt64s, stats.Int64s, stats.BytesSaved)
+}
+
+func readSchema(name string) *fdb.Schema {
+	file, err := os.Open(name)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer file.Close()
+
+	schema, err := fdb.ReadSchema(file)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	return schema
+}
+
 func main() {
 	flagset := flag.NewFlagSet("gb-fdb", flag.ExitOnError)
 	flagset.BoolVar(&VerboseFlag, "v", false, "Enable verbose logging.")
 	flagset.StringVar(&ExcludeTable, "excludeTable", "", "The name of the table that indicates which columns to exclude when converting to FDB. The game originally used the DBExclude table. See: https://docs.lu-dev.net/en/latest/database/DBExclude.html")
-	flagset.StringVar(&DriverName, "driver", "sqlite3", "Supported drivers: sqlite3")
+	flagset.IntVar(&MaxBufferedRows, "maxBufferedRows", 0, "The maximum number of rows per table to hold in memory when converting to FDB. Larger tables are queried twice and written as they are read, and only this many unique strings and 64-bit integers are shared across the file. If the value is <= 0, every table is held in memory.")
+	flagset.BoolVar(&SortBuckets, "sortBuckets", false, "When converting to FDB, sort the rows within each bucket by their key, similar to the files shipped with the client. Does not apply to tables larger than -maxBufferedRows. Implies -deterministic.")
+	flagset.BoolVar(&Deterministic, "deterministic", false, "When converting to FDB, query rows ordered by their columns, so that converting the same database always produces an identical FDB file.")
+	buckets := flagset.String("buckets", "", "A comma separated list of <table>=<# of buckets> used when converting to FDB. Tables which are not listed use the smallest power of 2 >= their number of unique keys. Use \"goverbuild fdb stats\" to find tables with long bucket chains.")
+	flagset.StringVar(&SchemaFile, "schema", "", "A JSON schema file, as exported by \"goverbuild fdb schema\". When converting to FDB, the schema's column variants are used instead of the variants guessed from the database's column types.")
+	flagset.BoolVar(&KeepColumnOrder, "keepColumnOrder", false, "When converting to FDB, keep the database's column order instead of moving the primary key to the front of each table.")
+	tables := flagset.String("tables", "", "A comma separated list of tables to import when converting from FDB. If empty, every table is imported.")
+	flagset.BoolVar(&KeepTables, "keep", false, "When converting from FDB, append rows to existing tables instead of dropping and recreating them.")
+	flagset.BoolVar(&ResumeImport, "resume", false, "When converting from FDB, skip existing tables that already contain every row of the FDB table. Incomplete tables are dropped and imported again.")
+	indexFile := flagset.String("indexes", "", "A JSON file of extra indexes and foreign keys to create when converting from FDB.")
+	maxDiffs := flagset.Int("maxDiffs", 20, "The maximum number of differences printed by verify. If the value is <= 0, every difference is printed.")
+	flagset.StringVar(&DriverName, "driver", "sqlite3", "Supported drivers: sqlite3, postgres, mysql")
 	flagset.Usage = usage(flagset)
 
 	if len(os.Args) < 2 {
@@ -89,6 +217,26 @@ func main() {
 
 	flagset.Parse(os.Args[2:])
 
+	if len(*tables) > 0 {
+		ImportTables = strings.Split(*tables, ",")
+	}
+
+	if len(*buckets) > 0 {
+		numBuckets, err := parseNumBuckets(*buckets)
+		if err != nil {
+			Error.Fatal(err)
+		}
+		NumBuckets = numBuckets
+	}
+
+	if len(SchemaFile) > 0 {
+		ForcedSchema = readSchema(SchemaFile)
+	}
+
+	if len(*indexFile) > 0 {
+		Indexes = readIndexSpec(*indexFile)
+	}
+
 	switch subcommand := os.Args[1]; subcommand {
 	case "toFdb":
 		input := flagset.Arg(0)
@@ -123,7 +271,11 @@ func main() {
 		}
 		defer file.Close()
 
-		if err := converter.WriteFdb(file, excludes); err != nil {
+		if err := converter.WriteFdb(file, TableOptions{
+			Excludes:        excludes,
+			KeepColumnOrder: KeepColumnOrder,
+			OrderRows:       Deterministic || SortBuckets,
+		}); err != nil {
 			Error.Fatal(err)
 		}
 	case "fromFdb":
@@ -148,10 +300,75 @@ func main() {
 		if err != nil {
 			Error.Fatal(err)
 		}
+		defer r.Close()
 
 		if err := converter.ReadFdb(r); err != nil {
 			Error.Fatal(err)
 		}
+	case "validate":
+		input := flagset.Arg(0)
+		if len(input) == 0 {
+			Error.Fatal("missing database DSN")
+		}
+
+		if len(SchemaFile) == 0 {
+			Error.Fatal("missing schema file")
+		}
+
+		converter, err := GetConverter(DriverName, input)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		tables, rows, err := converter.Tables(TableOptions{KeepColumnOrder: KeepColumnOrder})
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		errs, err := ForcedSchema.Validate(tables, rows)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+	case "verify":
+		input := flagset.Arg(0)
+		if len(input) == 0 {
+			Error.Fatal("missing fdb file")
+		}
+
+		dsn := flagset.Arg(1)
+		if len(dsn) == 0 {
+			Error.Fatal("missing database DSN")
+		}
+
+		converter, err := GetConverter(DriverName, dsn)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		r, err := fdb.OpenReader(input)
+		if err != nil {
+			Error.Fatal(err)
+		}
+		defer r.Close()
+
+		numDiffs, err := verify(converter, r, *maxDiffs)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		if numDiffs > 0 {
+			fmt.Printf("%d difference(s)\n", numDiffs)
+			os.Exit(1)
+		}
+		Verbose.Print("no differences")
 	default:
 		Error.Fatalf("unknown subcommand: %s", subcommand)
 	}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// A database/sql driver which records every statement and
+// returns canned rows, standing in for the values returned
+// by the postgres and mysql drivers.
+type fakeDriver struct{}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+type fakeExec struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeDB struct {
+	mu      sync.Mutex
+	results map[string]fakeResult
+	queries []string
+	execs   []fakeExec
+}
+
+var fakeDBs sync.Map
+
+func init() {
+	sql.Register("fake", fakeDriver{})
+}
+
+func openFake(t *testing.T) (*sql.DB, *fakeDB) {
+	fake := &fakeDB{results: map[string]fakeResult{}}
+	fakeDBs.Store(t.Name(), fake)
+	t.Cleanup(func() {
+		fakeDBs.Delete(t.Name())
+	})
+
+	db, err := sql.Open("fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db, fake
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fake, ok := fakeDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown database: %s", name)
+	}
+	return fakeConn{fake.(*fakeDB)}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.db, query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+// Accepts every value as-is, like drivers which support uint64.
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.execs = append(s.db.execs, fakeExec{s.query, args})
+	return driver.RowsAffected(0), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.queries = append(s.db.queries, s.query)
+
+	result, ok := s.db.results[s.query]
+	if !ok {
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+// Builds an FDB file within the test's temporary directory.
+func buildFdb(t *testing.T, tables []*fdb.Table, rows map[string][]fdb.Row) *fdb.Reader {
+	name := filepath.Join(t.TempDir(), "test.fdb")
+
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := fdb.NewBuilder(file, tables)
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return func(yield func(fdb.Row, error) bool) {
+			for _, row := range rows[tableName] {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	r, err := fdb.OpenReader(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+	})
+
+	return r
+}
+
+func rowValues(t *testing.T, row fdb.Row) []any {
+	values := make([]any, len(row))
+	for i := range row {
+		v, err := row.Value(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// Every variant, and the value read back from each column
+// of the rows returned by [allVariantsTable].
+var allVariants = []struct {
+	variant fdb.Variant
+	value   any
+}{
+	{fdb.VariantI32, int32(-1)},
+	{fdb.VariantU32, uint32(4294967295)},
+	{fdb.VariantReal, float32(1.5)},
+	{fdb.VariantNVarChar, "nvarchar"},
+	{fdb.VariantBool, true},
+	{fdb.VariantI64, int64(-9223372036854775808)},
+	{fdb.VariantU64, uint64(18446744073709551615)},
+	{fdb.VariantText, "text"},
+	{fdb.VariantNull, nil},
+}
+
+func allVariantsTable() *fdb.Table {
+	table := &fdb.Table{Name: "Variants"}
+	for _, v := range allVariants {
+		table.Columns = append(table.Columns, &fdb.Column{Variant: v.variant, Name: v.variant.String()})
+	}
+	return table
+}
+
+// Reads back rows of the values returned by a driver for the
+// columns of [allVariantsTable], and checks them against allVariants.
+func checkReadback(t *testing.T, quote quoteFunc, values []driver.Value) {
+	db, fake := openFake(t)
+
+	table := allVariantsTable()
+	query := selectQuery(table, quote, false)
+
+	columns := []string{}
+	for _, col := range table.Columns {
+		columns = append(columns, col.Name)
+	}
+	fake.results[query] = fakeResult{columns, [][]driver.Value{values}}
+
+	n := 0
+	for row, err := range iterRows(db, table, quote, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+
+		for i, actual := range rowValues(t, row) {
+			if expected := allVariants[i].value; actual != expected {
+				t.Errorf("%v: expected %v (%T) but got %v (%T)", allVariants[i].variant, expected, expected, actual, actual)
+			}
+
+			if row[i].Variant() != allVariants[i].variant {
+				t.Errorf("%v: read %v entry", allVariants[i].variant, row[i].Variant())
+			}
+		}
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 row but got %d", n)
+	}
+}
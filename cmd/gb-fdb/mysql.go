@@ -0,0 +1,304 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+const (
+	// The maximum number of placeholders within a single statement.
+	mysqlMaxParams = 65535
+
+	// The number of characters of TEXT columns to index, since
+	// MySQL cannot index the entire value of TEXT columns.
+	mysqlIndexPrefix = 255
+)
+
+type Mysql struct {
+	*sql.DB
+}
+
+func NewMysql(db *sql.DB) Converter {
+	return &Mysql{db}
+}
+
+func (db Mysql) toVariant(dataType, colType string) (fdb.Variant, bool) {
+	unsigned := strings.Contains(strings.ToLower(colType), "unsigned")
+
+	switch strings.ToLower(dataType) {
+	case "tinyint":
+		if strings.HasPrefix(strings.ToLower(colType), "tinyint(1)") {
+			return fdb.VariantBool, true
+		}
+		return fdb.VariantI32, true
+	case "smallint", "mediumint":
+		return fdb.VariantI32, true
+	case "int", "integer":
+		if unsigned {
+			return fdb.VariantU32, true
+		}
+		return fdb.VariantI32, true
+	case "float", "double":
+		return fdb.VariantReal, true
+	case "char", "varchar", "tinytext", "text", "mediumtext":
+		return fdb.VariantNVarChar, true
+	case "bool", "boolean":
+		return fdb.VariantBool, true
+	case "bigint":
+		if unsigned {
+			return fdb.VariantU64, true
+		}
+		return fdb.VariantI64, true
+	case "longtext", "blob", "mediumblob", "longblob":
+		return fdb.VariantText, true
+	default:
+		return fdb.VariantNull, false
+	}
+}
+
+func (db Mysql) toColType(variant fdb.Variant) (string, bool) {
+	switch variant {
+	case fdb.VariantI32:
+		return "INT", true
+	case fdb.VariantU32:
+		return "INT UNSIGNED", true
+	case fdb.VariantReal:
+		return "FLOAT", true
+	case fdb.VariantNVarChar:
+		return "TEXT", true
+	case fdb.VariantBool:
+		return "BOOLEAN", true
+	case fdb.VariantI64:
+		return "BIGINT", true
+	case fdb.VariantU64:
+		return "BIGINT UNSIGNED", true
+	case fdb.VariantText:
+		return "LONGTEXT", true
+	case fdb.VariantNull:
+		return "LONGBLOB", true
+	default:
+		return "", false
+	}
+}
+
+// Returns the comment which stores the variant of null columns,
+// since LONGBLOB columns are otherwise read as text columns.
+func (db Mysql) columnComment(variant fdb.Variant) (string, bool) {
+	if variant == fdb.VariantNull {
+		return variantComment(variant), true
+	}
+	return "", false
+}
+
+func (db Mysql) queryTable(name string, options TableOptions) (*fdb.Table, error) {
+	query := "SELECT column_name, data_type, column_type, column_comment, column_key = 'PRI' FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+	Verbose.Print(name, ": ", query)
+
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []columnInfo{}
+	for rows.Next() {
+		var dataType, comment string
+
+		column := columnInfo{}
+		if err := rows.Scan(&column.Name, &dataType, &column.Type, &comment, &column.IsPrimaryKey); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		column.Variant, column.Known = db.toVariant(dataType, column.Type)
+		column.readComment(comment)
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return newTable(name, columns, options.Excludes[name], options.KeepColumnOrder)
+}
+
+func (db Mysql) tableNames() ([]string, error) {
+	query := "SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name"
+	Verbose.Println(query)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (db Mysql) collectTables(options TableOptions) ([]*fdb.Table, error) {
+	names, err := db.tableNames()
+	if err != nil {
+		return nil, fmt.Errorf("collect tables: %v", err)
+	}
+
+	tables := []*fdb.Table{}
+	for _, name := range names {
+		if exclude, ok := options.Excludes[name]; ok && exclude.All {
+			Verbose.Print("excluding table \"", name, "\"")
+			continue
+		}
+
+		table, err := db.queryTable(name, options)
+		if err != nil {
+			return nil, fmt.Errorf("collect tables: query table: %s: %v", name, err)
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+func (db Mysql) Tables(options TableOptions) ([]*fdb.Table, fdb.RowsFunc, error) {
+	tables, err := db.collectTables(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mysql: %v", err)
+	}
+
+	byName := map[string]*fdb.Table{}
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	return tables, IterTables(db.DB, byName, quoteBacktick, options.OrderRows), nil
+}
+
+func (db Mysql) WriteFdb(w io.WriteSeeker, options TableOptions) error {
+	tables, rows, err := db.Tables(options)
+	if err != nil {
+		return err
+	}
+
+	builder := fdb.NewBuilder(w, tables, builderOptions())
+	if err := builder.Flush(rows); err != nil {
+		return fmt.Errorf("mysql: %v", err)
+	}
+	logBuildStats(builder.Stats())
+
+	return nil
+}
+
+func (db Mysql) countRows(tableName string) (int, error) {
+	return countRows(db.DB, tableName, quoteBacktick)
+}
+
+func (db Mysql) rowValues(row fdb.Row, values []any) error {
+	for i := range values {
+		v, err := row.Value(i)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+	}
+	return nil
+}
+
+// Foreign keys are not declared, since MySQL checks every
+// inserted row against the referenced table. Only the
+// referencing column is indexed. See [tableIndexes].
+func (db Mysql) createTable(table *fdb.Table) error {
+	colDefs := strings.Builder{}
+	for i, col := range table.Columns {
+		colType, ok := db.toColType(col.Variant)
+		if !ok {
+			return fmt.Errorf("%s: invalid column variant: %v", col.Name, col.Variant)
+		}
+
+		if i > 0 {
+			colDefs.WriteRune(',')
+		}
+
+		colDefs.WriteString(quoteBacktick(col.Name))
+		colDefs.WriteRune(' ')
+		colDefs.WriteString(colType)
+
+		if comment, ok := db.columnComment(col.Variant); ok {
+			colDefs.WriteString(" COMMENT '")
+			colDefs.WriteString(comment)
+			colDefs.WriteRune('\'')
+		}
+	}
+
+	query := fmt.Sprint("CREATE TABLE ", quoteBacktick(table.Name), " (", colDefs.String(), ")")
+	Verbose.Println(query)
+
+	_, err := db.Exec(query)
+	return err
+}
+
+// MySQL implicitly commits DDL statements, so only the inserts
+// are run within a transaction. A table left empty by a failed
+// import is imported again when resuming.
+func (db Mysql) importTable(table *fdb.Table, options importOptions) error {
+	if options.Drop {
+		query := fmt.Sprint("DROP TABLE IF EXISTS ", quoteBacktick(table.Name))
+		Verbose.Println(query)
+
+		if _, err := db.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	if options.Create {
+		if err := db.createTable(table); err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertRows(tx, table, quoteBacktick, mysqlMaxParams, db.rowValues); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if options.Create {
+		return createIndexes(db.Exec, table, options.Indexes, quoteBacktick, mysqlIndexPrefix)
+	}
+
+	return nil
+}
+
+func (db Mysql) ReadFdb(r *fdb.Reader) error {
+	if err := importFdb(db, r); err != nil {
+		return fmt.Errorf("mysql: %v", err)
+	}
+	return nil
+}
+
+func (db Mysql) GetExcludeTable(tableName string) (map[string]*Exclude, error) {
+	return queryExcludes(db.DB, fmt.Sprint("SELECT `table`, `column` FROM ", quoteBacktick(tableName)))
+}
+
+func (db Mysql) quote(name string) string {
+	return quoteBacktick(name)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql/driver"
+	"slices"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// The data_type and column_type reported by information_schema.columns
+// for each column type created by [Mysql.toColType].
+var mysqlColumnTypes = map[string][2]string{
+	"INT":             {"int", "int"},
+	"INT UNSIGNED":    {"int", "int unsigned"},
+	"FLOAT":           {"float", "float"},
+	"TEXT":            {"text", "text"},
+	"BOOLEAN":         {"tinyint", "tinyint(1)"},
+	"BIGINT":          {"bigint", "bigint"},
+	"BIGINT UNSIGNED": {"bigint", "bigint unsigned"},
+	"LONGTEXT":        {"longtext", "longtext"},
+	"LONGBLOB":        {"longblob", "longblob"},
+}
+
+func TestMysqlColumnTypes(t *testing.T) {
+	db := Mysql{}
+
+	for _, v := range allVariants {
+		colType, ok := db.toColType(v.variant)
+		if !ok {
+			t.Errorf("%v: no column type", v.variant)
+			continue
+		}
+
+		types, ok := mysqlColumnTypes[colType]
+		if !ok {
+			t.Errorf("%v: unexpected column type: %s", v.variant, colType)
+			continue
+		}
+
+		column := columnInfo{Type: types[1]}
+		column.Variant, column.Known = db.toVariant(types[0], types[1])
+		if comment, ok := db.columnComment(v.variant); ok {
+			column.readComment(comment)
+		}
+
+		if !column.Known || column.Variant != v.variant {
+			t.Errorf("%v: %s column read back as %v", v.variant, colType, column.Variant)
+		}
+	}
+
+	// MySQL 5.7 includes display widths.
+	if variant, _ := db.toVariant("int", "int(10) unsigned"); variant != fdb.VariantU32 {
+		t.Errorf("expected int(10) unsigned to be u32 but got %v", variant)
+	}
+
+	if _, ok := db.toVariant("geometry", "geometry"); ok {
+		t.Error("expected no variant for an unknown column type")
+	}
+}
+
+func TestMysqlReadback(t *testing.T) {
+	// The text protocol returns every value as []byte.
+	checkReadback(t, quoteBacktick, []driver.Value{
+		[]byte("-1"),
+		[]byte("4294967295"),
+		[]byte("1.5"),
+		[]byte("nvarchar"),
+		[]byte("1"),
+		[]byte("-9223372036854775808"),
+		[]byte("18446744073709551615"),
+		[]byte("text"),
+		nil,
+	})
+}
+
+func TestInsertRows(t *testing.T) {
+	table := &fdb.Table{Name: "Items", Columns: []*fdb.Column{{Variant: fdb.VariantI32, Name: "id"}, {Variant: fdb.VariantU64, Name: "value"}}}
+
+	rows := []fdb.Row{}
+	for i := range 8 {
+		rows = append(rows, fdb.Row{fdb.NewEntry(fdb.VariantI32, int32(i)), fdb.NewEntry(fdb.VariantU64, uint64(18446744073709551615)-uint64(i))})
+	}
+
+	r := buildFdb(t, []*fdb.Table{table}, map[string][]fdb.Row{"Items": rows})
+	table, _ = r.FindTable("Items")
+
+	expected := []driver.Value{}
+	for row, err := range table.Rows() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, v := range rowValues(t, row) {
+			expected = append(expected, v)
+		}
+	}
+
+	if query := insertQuery(table, 2, quoteBacktick); query != "INSERT INTO `Items` (`id`,`value`) VALUES (?,?),(?,?)" {
+		t.Errorf("unexpected query: %s", query)
+	}
+
+	tests := map[string]struct {
+		maxParams int
+		batches   []int
+	}{
+		// 7 placeholders fit 3 rows of 2 columns.
+		"partial": {7, []int{3, 3, 2}},
+		"exact":   {8, []int{4, 4}},
+		"single":  {1, []int{1, 1, 1, 1, 1, 1, 1, 1}},
+		"max":     {mysqlMaxParams, []int{8}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, fake := openFake(t)
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := insertRows(tx, table, quoteBacktick, test.maxParams, Mysql{}.rowValues); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(fake.execs) != len(test.batches) {
+				t.Fatalf("expected %d statements but got %d", len(test.batches), len(fake.execs))
+			}
+
+			actual := []driver.Value{}
+			for i, exec := range fake.execs {
+				if query := insertQuery(table, test.batches[i], quoteBacktick); exec.query != query {
+					t.Errorf("statement %d: expected %s but got %s", i, query, exec.query)
+				}
+				actual = append(actual, exec.args...)
+			}
+
+			if !slices.Equal(expected, actual) {
+				t.Errorf("expected %v but got %v", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+	"github.com/lib/pq"
+)
+
+type Postgres struct {
+	*sql.DB
+}
+
+func NewPostgres(db *sql.DB) Converter {
+	return &Postgres{db}
+}
+
+func (db Postgres) toVariant(colType string) (fdb.Variant, bool) {
+	switch strings.ToLower(colType) {
+	case "smallint", "integer":
+		return fdb.VariantI32, true
+	case "real", "double precision":
+		return fdb.VariantReal, true
+	case "character varying", "character":
+		return fdb.VariantNVarChar, true
+	case "boolean":
+		return fdb.VariantBool, true
+	case "bigint":
+		return fdb.VariantI64, true
+	case "numeric":
+		return fdb.VariantU64, true
+	case "text", "bytea":
+		return fdb.VariantText, true
+	default:
+		return fdb.VariantNull, false
+	}
+}
+
+func (db Postgres) toColType(variant fdb.Variant) (string, bool) {
+	switch variant {
+	case fdb.VariantI32:
+		return "INTEGER", true
+	case fdb.VariantU32:
+		return "BIGINT", true
+	case fdb.VariantReal:
+		return "REAL", true
+	case fdb.VariantNVarChar:
+		return "VARCHAR", true
+	case fdb.VariantBool:
+		return "BOOLEAN", true
+	case fdb.VariantI64:
+		return "BIGINT", true
+	case fdb.VariantU64:
+		return "NUMERIC(20)", true
+	case fdb.VariantText, fdb.VariantNull:
+		return "TEXT", true
+	default:
+		return "", false
+	}
+}
+
+// Returns the comment which stores the variant of u32 and null
+// columns, since they share their column type with other variants.
+func (db Postgres) columnComment(variant fdb.Variant) (string, bool) {
+	if variant == fdb.VariantU32 || variant == fdb.VariantNull {
+		return variantComment(variant), true
+	}
+	return "", false
+}
+
+func (db Postgres) queryTable(name string, options TableOptions) (*fdb.Table, error) {
+	query := `SELECT c.column_name, c.data_type,
+	COALESCE(col_description(to_regclass(quote_ident(c.table_schema) || '.' || quote_ident(c.table_name)), c.ordinal_position::int), ''),
+	EXISTS (
+		SELECT 1 FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage k ON k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name AND k.column_name = c.column_name
+	)
+FROM information_schema.columns c
+WHERE c.table_schema = current_schema() AND c.table_name = $1
+ORDER BY c.ordinal_position`
+	Verbose.Print(name, ": ", query)
+
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []columnInfo{}
+	for rows.Next() {
+		var comment string
+
+		column := columnInfo{}
+		if err := rows.Scan(&column.Name, &column.Type, &comment, &column.IsPrimaryKey); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+
+		column.Variant, column.Known = db.toVariant(column.Type)
+		column.readComment(comment)
+
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return newTable(name, columns, options.Excludes[name], options.KeepColumnOrder)
+}
+
+func (db Postgres) tableNames() ([]string, error) {
+	query := "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name"
+	Verbose.Println(query)
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (db Postgres) collectTables(options TableOptions) ([]*fdb.Table, error) {
+	names, err := db.tableNames()
+	if err != nil {
+		return nil, fmt.Errorf("collect tables: %v", err)
+	}
+
+	tables := []*fdb.Table{}
+	for _, name := range names {
+		if exclude, ok := options.Excludes[name]; ok && exclude.All {
+			Verbose.Print("excluding table \"", name, "\"")
+			continue
+		}
+
+		table, err := db.queryTable(name, options)
+		if err != nil {
+			return nil, fmt.Errorf("collect tables: query table: %s: %v", name, err)
+		}
+
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+func (db Postgres) Tables(options TableOptions) ([]*fdb.Table, fdb.RowsFunc, error) {
+	tables, err := db.collectTables(options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("postgres: %v", err)
+	}
+
+	byName := map[string]*fdb.Table{}
+	for _, table := range tables {
+		byName[table.Name] = table
+	}
+
+	return tables, IterTables(db.DB, byName, quoteDouble, options.OrderRows), nil
+}
+
+func (db Postgres) WriteFdb(w io.WriteSeeker, options TableOptions) error {
+	tables, rows, err := db.Tables(options)
+	if err != nil {
+		return err
+	}
+
+	builder := fdb.NewBuilder(w, tables, builderOptions())
+	if err := builder.Flush(rows); err != nil {
+		return fmt.Errorf("postgres: %v", err)
+	}
+	logBuildStats(builder.Stats())
+
+	return nil
+}
+
+func (db Postgres) countRows(tableName string) (int, error) {
+	return countRows(db.DB, tableName, quoteDouble)
+}
+
+func (db Postgres) rowValues(row fdb.Row, values []any) error {
+	for i := range values {
+		v, err := row.Value(i)
+		if err != nil {
+			return err
+		}
+
+		// database/sql does not accept uint64 values with the
+		// high bit set, so they are sent as NUMERIC text instead.
+		if u, ok := v.(uint64); ok {
+			v = strconv.FormatUint(u, 10)
+		}
+
+		values[i] = v
+	}
+	return nil
+}
+
+// Foreign keys are not declared, since PostgreSQL requires a
+// unique constraint on the referenced column. Only the
+// referencing column is indexed. See [tableIndexes].
+func (db Postgres) createTable(tx *sql.Tx, table *fdb.Table) error {
+	colDefs := strings.Builder{}
+	comments := []string{}
+	for i, col := range table.Columns {
+		colType, ok := db.toColType(col.Variant)
+		if !ok {
+			return fmt.Errorf("%s: invalid column variant: %v", col.Name, col.Variant)
+		}
+
+		if i > 0 {
+			colDefs.WriteRune(',')
+		}
+
+		colDefs.WriteString(quoteDouble(col.Name))
+		colDefs.WriteRune(' ')
+		colDefs.WriteString(colType)
+
+		if comment, ok := db.columnComment(col.Variant); ok {
+			comments = append(comments, fmt.Sprint("COMMENT ON COLUMN ", quoteDouble(table.Name), ".", quoteDouble(col.Name), " IS '", comment, "'"))
+		}
+	}
+
+	query := fmt.Sprint("CREATE TABLE ", quoteDouble(table.Name), " (", colDefs.String(), ")")
+	Verbose.Println(query)
+
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+
+	for _, query := range comments {
+		Verbose.Println(query)
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db Postgres) copyRows(tx *sql.Tx, table *fdb.Table) error {
+	colNames := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		colNames[i] = col.Name
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn(table.Name, colNames...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]any, len(table.Columns))
+	for row, err := range table.Rows() {
+		if err != nil {
+			return err
+		}
+
+		if err := db.rowValues(row, values); err != nil {
+			return err
+		}
+
+		if _, err := stmt.Exec(values...); err != nil {
+			return err
+		}
+	}
+
+	_, err = stmt.Exec()
+	return err
+}
+
+// Imports the table within a single transaction, so a failed
+// import leaves the existing table untouched.
+func (db Postgres) importTable(table *fdb.Table, options importOptions) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if options.Drop {
+		query := fmt.Sprint("DROP TABLE IF EXISTS ", quoteDouble(table.Name))
+		Verbose.Println(query)
+
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	if options.Create {
+		if err := db.createTable(tx, table); err != nil {
+			return err
+		}
+	}
+
+	if err := db.copyRows(tx, table); err != nil {
+		return err
+	}
+
+	if options.Create {
+		if err := createIndexes(tx.Exec, table, options.Indexes, quoteDouble, 0); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (db Postgres) ReadFdb(r *fdb.Reader) error {
+	if err := importFdb(db, r); err != nil {
+		return fmt.Errorf("postgres: %v", err)
+	}
+	return nil
+}
+
+func (db Postgres) GetExcludeTable(tableName string) (map[string]*Exclude, error) {
+	return queryExcludes(db.DB, fmt.Sprint(`SELECT "table", "column" FROM `, quoteDouble(tableName)))
+}
+
+func (db Postgres) quote(name string) string {
+	return quoteDouble(name)
+}
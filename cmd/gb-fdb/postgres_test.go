@@ -0,0 +1,94 @@
+package main
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// The data_type reported by information_schema.columns
+// for each column type created by [Postgres.toColType].
+var postgresDataTypes = map[string]string{
+	"INTEGER":     "integer",
+	"BIGINT":      "bigint",
+	"REAL":        "real",
+	"VARCHAR":     "character varying",
+	"BOOLEAN":     "boolean",
+	"NUMERIC(20)": "numeric",
+	"TEXT":        "text",
+}
+
+func TestPostgresColumnTypes(t *testing.T) {
+	db := Postgres{}
+
+	for _, v := range allVariants {
+		colType, ok := db.toColType(v.variant)
+		if !ok {
+			t.Errorf("%v: no column type", v.variant)
+			continue
+		}
+
+		dataType, ok := postgresDataTypes[colType]
+		if !ok {
+			t.Errorf("%v: unexpected column type: %s", v.variant, colType)
+			continue
+		}
+
+		column := columnInfo{Type: dataType}
+		column.Variant, column.Known = db.toVariant(dataType)
+		if comment, ok := db.columnComment(v.variant); ok {
+			column.readComment(comment)
+		}
+
+		if !column.Known || column.Variant != v.variant {
+			t.Errorf("%v: %s column read back as %v", v.variant, colType, column.Variant)
+		}
+	}
+
+	if _, ok := db.toColType(fdb.Variant(9)); ok {
+		t.Error("expected no column type for an unknown variant")
+	}
+
+	if _, ok := db.toVariant("point"); ok {
+		t.Error("expected no variant for an unknown column type")
+	}
+}
+
+func TestPostgresReadback(t *testing.T) {
+	// lib/pq returns NUMERIC and BYTEA columns as []byte.
+	checkReadback(t, quoteDouble, []driver.Value{
+		int64(-1),
+		int64(4294967295),
+		float64(1.5),
+		"nvarchar",
+		true,
+		int64(-9223372036854775808),
+		[]byte("18446744073709551615"),
+		[]byte("text"),
+		nil,
+	})
+}
+
+func TestPostgresRowValues(t *testing.T) {
+	row := fdb.Row{}
+	for _, v := range allVariants {
+		row = append(row, fdb.NewEntry(v.variant, v.value))
+	}
+
+	values := make([]any, len(row))
+	if err := (Postgres{}).rowValues(row, values); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range allVariants {
+		expected := v.value
+		if v.variant == fdb.VariantU64 {
+			expected = "18446744073709551615"
+		}
+
+		if values[i] != expected {
+			t.Errorf("%v: expected %v (%T) but got %v (%T)", v.variant, expected, expected, values[i], values[i])
+		}
+	}
+}
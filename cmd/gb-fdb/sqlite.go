@@ -4,12 +4,15 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
-	"slices"
 	"strings"
 
 	"github.com/I-Am-Dench/goverbuild/database/fdb"
 )
 
+// The default maximum number of placeholders
+// within a single statement since SQLite 3.32.0.
+const sqliteMaxParams = 32766
+
 type Sqlite struct {
 	*sql.DB
 }
@@ -22,6 +25,8 @@ func (db Sqlite) toVariant(colType string) (fdb.Variant, bool) {
 	switch strings.ToUpper(colType) {
 	case "INTEGER", "INT", "INT32":
 		return fdb.VariantI32, true
+	case "UINT32":
+		return fdb.VariantU32, true
 	case "REAL":
 		return fdb.VariantReal, true
 	case "TEXT", "TEXT4":
@@ -30,6 +35,8 @@ func (db Sqlite) toVariant(colType string) (fdb.Variant, bool) {
 		return fdb.VariantBool, true
 	case "INT64":
 		return fdb.VariantI64, true
+	case "UINT64":
+		return fdb.VariantU64, true
 	case "TEXT8", "TEXT_XML", "BLOB", "BLOB_NONE":
 		return fdb.VariantText, true
 	default:
@@ -41,6 +48,8 @@ func (db Sqlite) toColType(variant fdb.Variant) (string, bool) {
 	switch variant {
 	case fdb.VariantI32:
 		return "INT32", true
+	case fdb.VariantU32:
+		return "UINT32", true
 	case fdb.VariantReal:
 		return "REAL", true
 	case fdb.VariantNVarChar:
@@ -49,6 +58,8 @@ func (db Sqlite) toColType(variant fdb.Variant) (string, bool) {
 		return "INT_BOOL", true
 	case fdb.VariantI64:
 		return "INT64", true
+	case fdb.VariantU64:
+		return "UINT64", true
 	case fdb.VariantText:
 		return "BLOB", true
 	case fdb.VariantNull:
@@ -58,13 +69,7 @@ func (db Sqlite) toColType(variant fdb.Variant) (string, bool) {
 	}
 }
 
-func moveToFront(columns []*fdb.Column, i int) {
-	for j := i - 1; j >= 0; j-- {
-		columns[j], columns[j+1] = columns[j+1], columns[j]
-	}
-}
-
-func (db Sqlite) queryTable(name string, exclude *Exclude) (*fdb.Table, error) {
+func (db Sqlite) queryTable(name string, options TableOptions) (*fdb.Table, error) {
 	query := "SELECT name, type, pk FROM pragma_table_info(?)"
 	Verbose.Print(name, ": ", query)
 
@@ -72,82 +77,62 @@ func (db Sqlite) queryTable(name string, exclude *Exclude) (*fdb.Table, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	primaryKeyIndex := -1
-	i := 0
-
-	table := &fdb.Table{
-		Name:    name,
-		Columns: []*fdb.Column{},
-	}
+	columns := []columnInfo{}
 	for rows.Next() {
-		var (
-			colName      string
-			colType      string
-			isPrimaryKey bool
-		)
-		if err := rows.Scan(&colName, &colType, &isPrimaryKey); err != nil {
+		column := columnInfo{}
+		if err := rows.Scan(&column.Name, &column.Type, &column.IsPrimaryKey); err != nil {
 			return nil, fmt.Errorf("%s: %v", name, err)
 		}
 
-		variant, ok := db.toVariant(colType)
-		if !ok {
-			return nil, fmt.Errorf("%s: unknown column type: %s", colName, colType)
-		}
-
-		if exclude != nil && slices.Contains(exclude.Columns, colName) {
-			Verbose.Print(name, ": excluding column \"", colName, "\"")
-			i++
-			continue
-		}
-
-		table.Columns = append(table.Columns, &fdb.Column{
-			Variant: variant,
-			Name:    colName,
-		})
-
-		if primaryKeyIndex < 0 && isPrimaryKey {
-			Verbose.Print("Found primary key \"", colName, "\"")
-			primaryKeyIndex = i
-		}
-		i++
+		column.Variant, column.Known = db.toVariant(column.Type)
+		columns = append(columns, column)
 	}
 
-	if primaryKeyIndex > 0 {
-		moveToFront(table.Columns, primaryKeyIndex)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
 	}
 
-	return table, nil
+	return newTable(name, columns, options.Excludes[name], options.KeepColumnOrder)
 }
 
-func (db Sqlite) collectTables(excludes map[string]*Exclude) ([]*fdb.Table, error) {
+func (db Sqlite) tableNames() ([]string, error) {
 	query := "SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%'"
 	Verbose.Println(query)
 
 	rows, err := db.Query(query)
 	if err != nil {
-		return nil, fmt.Errorf("collect tables: %v", err)
+		return nil, err
 	}
+	defer rows.Close()
 
 	names := []string{}
 	for rows.Next() {
 		var name string
 		if err := rows.Scan(&name); err != nil {
-			return nil, fmt.Errorf("collect tables: %v", err)
+			return nil, err
 		}
+		names = append(names, name)
+	}
 
-		exclude, ok := excludes[name]
-		if ok && exclude.All {
-			Verbose.Print("excluding table \"", name, "\"")
-			continue
-		}
+	return names, rows.Err()
+}
 
-		names = append(names, name)
+func (db Sqlite) collectTables(options TableOptions) ([]*fdb.Table, error) {
+	names, err := db.tableNames()
+	if err != nil {
+		return nil, fmt.Errorf("collect tables: %v", err)
 	}
 
 	tables := []*fdb.Table{}
 	for _, name := range names {
-		table, err := db.queryTable(name, excludes[name])
+		if exclude, ok := options.Excludes[name]; ok && exclude.All {
+			Verbose.Print("excluding table \"", name, "\"")
+			continue
+		}
+
+		table, err := db.queryTable(name, options)
 		if err != nil {
 			return nil, fmt.Errorf("collect tables: query table: %s: %v", name, err)
 		}
@@ -158,10 +143,10 @@ func (db Sqlite) collectTables(excludes map[string]*Exclude) ([]*fdb.Table, erro
 	return tables, nil
 }
 
-func (db Sqlite) WriteFdb(w io.WriteSeeker, excludes map[string]*Exclude) error {
-	tables, err := db.collectTables(excludes)
+func (db Sqlite) Tables(options TableOptions) ([]*fdb.Table, fdb.RowsFunc, error) {
+	tables, err := db.collectTables(options)
 	if err != nil {
-		return fmt.Errorf("sqlite3: %v", err)
+		return nil, nil, fmt.Errorf("sqlite3: %v", err)
 	}
 
 	byName := map[string]*fdb.Table{}
@@ -169,37 +154,28 @@ func (db Sqlite) WriteFdb(w io.WriteSeeker, excludes map[string]*Exclude) error
 		byName[table.Name] = table
 	}
 
-	builder := fdb.NewBuilder(w, tables)
-	if err := builder.Flush(IterTables(db.DB, byName)); err != nil {
-		return fmt.Errorf("sqlite3: %v", err)
-	}
-
-	return nil
+	return tables, IterTables(db.DB, byName, quoteDouble, options.OrderRows), nil
 }
 
-func (db Sqlite) dropTables(tables []*fdb.Table) error {
-	tx, err := db.Begin()
+func (db Sqlite) WriteFdb(w io.WriteSeeker, options TableOptions) error {
+	tables, rows, err := db.Tables(options)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
-
-	for _, table := range tables {
-		query := fmt.Sprint("DROP TABLE IF EXISTS ", table.Name)
-		Verbose.Print(query)
-
-		if _, err := tx.Exec(query); err != nil {
-			return fmt.Errorf("drop %s: %v", table.Name, err)
-		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	builder := fdb.NewBuilder(w, tables, builderOptions())
+	if err := builder.Flush(rows); err != nil {
+		return fmt.Errorf("sqlite3: %v", err)
 	}
+	logBuildStats(builder.Stats())
 
 	return nil
 }
 
+func (db Sqlite) countRows(tableName string) (int, error) {
+	return countRows(db.DB, tableName, quoteDouble)
+}
+
 func (db Sqlite) rowValues(row fdb.Row, values []any) error {
 	for i := range values {
 		v, err := row.Value(i)
@@ -207,19 +183,20 @@ func (db Sqlite) rowValues(row fdb.Row, values []any) error {
 			return err
 		}
 
+		// SQLite integers are signed, so unsigned 64-bit
+		// values are stored by their bits.
+		if u, ok := v.(uint64); ok {
+			v = int64(u)
+		}
+
 		values[i] = v
 	}
 	return nil
 }
 
-func (db Sqlite) createTable(table *fdb.Table) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	placeholders := strings.Builder{}
+// Foreign keys are declared with REFERENCES, but are only
+// enforced if the connection enables foreign_keys.
+func (db Sqlite) createTable(tx *sql.Tx, table *fdb.Table, foreignKeys []ForeignKey) error {
 	colNames := strings.Builder{}
 	for i, col := range table.Columns {
 		colType, ok := db.toColType(col.Variant)
@@ -228,98 +205,78 @@ func (db Sqlite) createTable(table *fdb.Table) error {
 		}
 
 		if i > 0 {
-			placeholders.WriteRune(',')
 			colNames.WriteRune(',')
 		}
 
-		placeholders.WriteRune('?')
-
-		colNames.WriteRune('"')
-		colNames.WriteString(col.Name)
-		colNames.WriteString("\" ")
+		colNames.WriteString(quoteDouble(col.Name))
+		colNames.WriteRune(' ')
 		colNames.WriteString(colType)
+
+		for _, fk := range foreignKeys {
+			if fk.Column == col.Name {
+				fmt.Fprint(&colNames, " REFERENCES ", quoteDouble(fk.RefTable), " (", quoteDouble(fk.RefColumn), ")")
+				break
+			}
+		}
 	}
 
-	createQuery := fmt.Sprint("CREATE TABLE ", table.Name, " (", colNames.String(), ")")
-	Verbose.Println(createQuery)
+	query := fmt.Sprint("CREATE TABLE ", quoteDouble(table.Name), " (", colNames.String(), ")")
+	Verbose.Println(query)
 
-	if _, err := tx.Exec(createQuery); err != nil {
-		return err
-	}
+	_, err := tx.Exec(query)
+	return err
+}
 
-	rows, err := table.HashTable().Rows()
+// Imports the table within a single transaction, so a failed
+// import leaves the existing table untouched.
+func (db Sqlite) importTable(table *fdb.Table, options importOptions) error {
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	insertQuery := fmt.Sprint("INSERT INTO ", table.Name, " VALUES (", placeholders.String(), ")")
-	Verbose.Println(insertQuery)
+	if options.Drop {
+		query := fmt.Sprint("DROP TABLE IF EXISTS ", quoteDouble(table.Name))
+		Verbose.Println(query)
 
-	values := make([]any, len(table.Columns))
-	for rows.Next() {
-		if err := db.rowValues(rows.Row(), values); err != nil {
+		if _, err := tx.Exec(query); err != nil {
 			return err
 		}
+	}
 
-		if _, err := tx.Exec(insertQuery, values...); err != nil {
+	if options.Create {
+		if err := db.createTable(tx, table, options.ForeignKeys); err != nil {
 			return err
 		}
 	}
 
-	if err := rows.Err(); err != nil {
+	if err := insertRows(tx, table, quoteDouble, sqliteMaxParams, db.rowValues); err != nil {
 		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+	// Indexes are created after inserting, which is
+	// faster than updating them for every row.
+	if options.Create {
+		if err := createIndexes(tx.Exec, table, options.Indexes, quoteDouble, 0); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (db Sqlite) ReadFdb(r *fdb.Reader) error {
-	if err := db.dropTables(r.Tables()); err != nil {
-		return err
-	}
-
-	for _, table := range r.Tables() {
-		if err := db.createTable(table); err != nil {
-			return fmt.Errorf("create table %s: %v", table.Name, err)
-		}
+	if err := importFdb(db, r); err != nil {
+		return fmt.Errorf("sqlite3: %v", err)
 	}
-
 	return nil
 }
 
 func (db Sqlite) GetExcludeTable(tableName string) (map[string]*Exclude, error) {
-	excludes := make(map[string]*Exclude)
-
-	query := fmt.Sprint("SELECT \"table\", \"column\" FROM ", tableName)
-	Verbose.Println(query)
-
-	rows, err := db.Query(query)
-	if err != nil {
-		return nil, err
-	}
-
-	for rows.Next() {
-		var name, column string
-		if err := rows.Scan(&name, &column); err != nil {
-			return nil, err
-		}
-
-		exclude, ok := excludes[name]
-		if !ok {
-			exclude = &Exclude{}
-			excludes[name] = exclude
-		}
-
-		if column == "*" {
-			exclude.All = true
-		} else {
-			exclude.Columns = append(exclude.Columns, column)
-		}
-	}
+	return queryExcludes(db.DB, fmt.Sprint("SELECT \"table\", \"column\" FROM ", tableName))
+}
 
-	return excludes, nil
+func (db Sqlite) quote(name string) string {
+	return quoteDouble(name)
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// A column as described by a database's schema.
+type columnInfo struct {
+	Name         string
+	Type         string
+	IsPrimaryKey bool
+
+	// The variant guessed from the column's type. Known
+	// is false if the type has no corresponding variant.
+	Variant fdb.Variant
+	Known   bool
+}
+
+// Prefix of the column comments used to store a column's
+// variant when it cannot be recovered from the column's type.
+const variantCommentPrefix = "fdb:"
+
+func variantComment(variant fdb.Variant) string {
+	return fmt.Sprint(variantCommentPrefix, variant)
+}
+
+// Replaces the guessed variant with the variant
+// stored in the column's comment, if there is one.
+func (c *columnInfo) readComment(comment string) {
+	if text, ok := strings.CutPrefix(comment, variantCommentPrefix); ok {
+		var variant fdb.Variant
+		if err := variant.UnmarshalText([]byte(text)); err == nil {
+			c.Variant, c.Known = variant, true
+		}
+	}
+}
+
+func moveToFront(columns []*fdb.Column, i int) {
+	for j := i - 1; j >= 0; j-- {
+		columns[j], columns[j+1] = columns[j+1], columns[j]
+	}
+}
+
+// Options for reading the tables of a database.
+type TableOptions struct {
+	// Tables and columns to skip, by table name.
+	Excludes map[string]*Exclude
+
+	// Keep the database's column order instead of moving
+	// the first primary key to the front of each table.
+	KeepColumnOrder bool
+
+	// Query rows ordered by their columns, so that the same
+	// database always yields its rows in the same order.
+	OrderRows bool
+}
+
+// Creates an [*fdb.Table] from the columns of a database table.
+// Variants from [ForcedSchema] take priority over guessed variants,
+// excluded columns are skipped, and the first primary key is moved
+// to the front of the table unless keepColumnOrder is true.
+func newTable(name string, columns []columnInfo, exclude *Exclude, keepColumnOrder bool) (*fdb.Table, error) {
+	primaryKeyIndex := -1
+
+	table := &fdb.Table{
+		Name:    name,
+		Columns: []*fdb.Column{},
+	}
+	for _, column := range columns {
+		variant, ok := column.Variant, column.Known
+		if forced, found := ForcedSchema.Variant(name, column.Name); found {
+			if ok && forced != variant {
+				Verbose.Print(name, ": forcing column \"", column.Name, "\" (", column.Type, ") to ", forced)
+			}
+			variant, ok = forced, true
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown column type: %s", column.Name, column.Type)
+		}
+
+		if exclude != nil && slices.Contains(exclude.Columns, column.Name) {
+			Verbose.Print(name, ": excluding column \"", column.Name, "\"")
+			continue
+		}
+
+		if primaryKeyIndex < 0 && column.IsPrimaryKey {
+			Verbose.Print("Found primary key \"", column.Name, "\"")
+			primaryKeyIndex = len(table.Columns)
+		}
+
+		table.Columns = append(table.Columns, &fdb.Column{
+			Variant: variant,
+			Name:    column.Name,
+		})
+	}
+
+	if primaryKeyIndex > 0 && !keepColumnOrder {
+		moveToFront(table.Columns, primaryKeyIndex)
+	}
+
+	return table, nil
+}
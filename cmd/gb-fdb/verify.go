@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// Reports the differences found by [verify], printing
+// at most maxDiffs of them.
+type verifier struct {
+	maxDiffs int
+	numDiffs int
+}
+
+func (v *verifier) report(format string, args ...any) {
+	v.numDiffs++
+	if v.maxDiffs <= 0 || v.numDiffs <= v.maxDiffs {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
+// Formats a value read from an FDB file so that it can be compared
+// with the output of [sqlValueString].
+func fdbValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Formats a value read from a database as the value it would have
+// within a column of the provided variant. Values which do not fit
+// the variant, such as a REAL that is not exactly representable as
+// a float32, are formatted such that they do not match the output
+// of [fdbValueString].
+func sqlValueString(variant fdb.Variant, value any) string {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+
+	if s, ok := value.(string); ok {
+		switch variant {
+		case fdb.VariantI32, fdb.VariantI64:
+			if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+				value = i
+			}
+		case fdb.VariantU32, fdb.VariantU64:
+			if i, err := strconv.ParseUint(s, 10, 64); err == nil {
+				value = i
+			}
+		case fdb.VariantReal:
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				value = f
+			}
+		case fdb.VariantBool:
+			if b, err := strconv.ParseBool(s); err == nil {
+				value = b
+			}
+		}
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	case int64:
+		switch variant {
+		case fdb.VariantBool:
+			return strconv.FormatBool(v != 0)
+		case fdb.VariantU64:
+			// Unsigned 64-bit values may be stored by their bits.
+			return strconv.FormatUint(uint64(v), 10)
+		case fdb.VariantReal:
+			return strconv.FormatFloat(float64(v), 'g', -1, 64)
+		}
+	case uint64:
+		if variant == fdb.VariantBool {
+			return strconv.FormatBool(v != 0)
+		}
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 64)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func (v *verifier) verifyColumns(fdbTable, dbTable *fdb.Table) []*fdb.Column {
+	columns := []*fdb.Column{}
+	for i, column := range fdbTable.Columns {
+		j := slices.IndexFunc(dbTable.Columns, func(c *fdb.Column) bool { return c.Name == column.Name })
+		if j < 0 {
+			v.report("%s.%s: column missing from database", fdbTable.Name, column.Name)
+			continue
+		}
+		columns = append(columns, column)
+
+		if variant := dbTable.Columns[j].Variant; variant != column.Variant {
+			v.report("%s.%s: %v in fdb but %v in database", fdbTable.Name, column.Name, column.Variant, variant)
+		}
+
+		if i != j {
+			v.report("%s.%s: column %d in fdb but column %d in database", fdbTable.Name, column.Name, i, j)
+		}
+	}
+
+	for _, column := range dbTable.Columns {
+		if !hasColumn(fdbTable, column.Name) {
+			v.report("%s.%s: column missing from fdb", fdbTable.Name, column.Name)
+		}
+	}
+
+	return columns
+}
+
+type verifyRow struct {
+	values []string
+	count  int
+}
+
+func (r verifyRow) String() string {
+	return "(" + strings.Join(r.values, ", ") + ")"
+}
+
+func (v *verifier) queryRows(converter Converter, table *fdb.Table, columns []*fdb.Column) ([]*verifyRow, error) {
+	colNames := make([]string, len(columns))
+	for i, column := range columns {
+		colNames[i] = converter.quote(column.Name)
+	}
+
+	query := fmt.Sprint("SELECT ", strings.Join(colNames, ","), " FROM ", converter.quote(table.Name))
+	Verbose.Println(query)
+
+	rows, err := converter.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dbRows := []*verifyRow{}
+	indices := map[string]int{}
+
+	raw := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = sqlValueString(column.Variant, raw[i])
+		}
+
+		key := strings.Join(values, "\x00")
+		if i, ok := indices[key]; ok {
+			dbRows[i].count++
+			continue
+		}
+
+		indices[key] = len(dbRows)
+		dbRows = append(dbRows, &verifyRow{values: values, count: 1})
+	}
+
+	return dbRows, rows.Err()
+}
+
+// Matches the FDB rows with the database rows, first by their
+// key (first column) and then by their full contents.
+func (v *verifier) verifyRows(converter Converter, table *fdb.Table, columns []*fdb.Column) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	dbRows, err := v.queryRows(converter, table, columns)
+	if err != nil {
+		return err
+	}
+
+	indices := map[string]int{}
+	for i, row := range dbRows {
+		indices[strings.Join(row.values, "\x00")] = i
+	}
+
+	fdbOnly := []*verifyRow{}
+	for row, err := range table.Rows() {
+		if err != nil {
+			return err
+		}
+
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			j := slices.Index(table.Columns, column)
+
+			value, err := row.Value(j)
+			if err != nil {
+				return err
+			}
+			values[i] = fdbValueString(value)
+		}
+
+		if i, ok := indices[strings.Join(values, "\x00")]; ok && dbRows[i].count > 0 {
+			dbRows[i].count--
+			continue
+		}
+
+		fdbOnly = append(fdbOnly, &verifyRow{values: values, count: 1})
+	}
+
+	dbOnly := map[string][]*verifyRow{}
+	for _, row := range dbRows {
+		for range row.count {
+			dbOnly[row.values[0]] = append(dbOnly[row.values[0]], row)
+		}
+	}
+
+	for _, row := range fdbOnly {
+		key := row.values[0]
+		if candidates := dbOnly[key]; len(candidates) > 0 {
+			v.report("%s: row %s differs: %s in fdb but %s in database", table.Name, key, row, candidates[0])
+			candidates[0].count--
+			dbOnly[key] = candidates[1:]
+			continue
+		}
+
+		v.report("%s: row %s missing from database: %s", table.Name, key, row)
+	}
+
+	for _, row := range dbRows {
+		for range row.count {
+			v.report("%s: row %s missing from fdb: %s", table.Name, row.values[0], row)
+		}
+	}
+
+	return nil
+}
+
+// Compares the tables, columns, and rows of an FDB file and a
+// database, printing at most maxDiffs differences. If maxDiffs
+// is <= 0, every difference is printed. verify returns the
+// total number of differences.
+func verify(converter Converter, r *fdb.Reader, maxDiffs int) (int, error) {
+	// Compare against the database's actual column order.
+	dbTables, _, err := converter.Tables(TableOptions{KeepColumnOrder: true})
+	if err != nil {
+		return 0, err
+	}
+
+	v := &verifier{maxDiffs: maxDiffs}
+	for _, fdbTable := range r.Tables() {
+		i := slices.IndexFunc(dbTables, func(t *fdb.Table) bool { return t.Name == fdbTable.Name })
+		if i < 0 {
+			v.report("%s: table missing from database", fdbTable.Name)
+			continue
+		}
+
+		columns := v.verifyColumns(fdbTable, dbTables[i])
+		if err := v.verifyRows(converter, fdbTable, columns); err != nil {
+			return v.numDiffs, fmt.Errorf("verify %s: %v", fdbTable.Name, err)
+		}
+	}
+
+	for _, dbTable := range dbTables {
+		if _, ok := r.FindTable(dbTable.Name); !ok {
+			v.report("%s: table missing from fdb", dbTable.Name)
+		}
+	}
+
+	if maxDiffs > 0 && v.numDiffs > maxDiffs {
+		fmt.Printf("... and %d more\n", v.numDiffs-maxDiffs)
+	}
+
+	return v.numDiffs, nil
+}
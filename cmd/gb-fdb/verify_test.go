@@ -0,0 +1,97 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func openSqlite(t *testing.T) (*sql.DB, Converter) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db, NewSqlite(db)
+}
+
+func TestVerify(t *testing.T) {
+	r, err := fdb.OpenReader("../../database/fdb/testdata/basic.fdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	db, converter := openSqlite(t)
+	if err := converter.ReadFdb(r); err != nil {
+		t.Fatal(err)
+	}
+
+	numDiffs, err := verify(converter, r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if numDiffs != 0 {
+		t.Fatalf("expected no differences but got %d", numDiffs)
+	}
+
+	table := r.Tables()[0]
+	if _, err := db.Exec("DELETE FROM " + quoteDouble(table.Name) + " WHERE rowid = (SELECT MIN(rowid) FROM " + quoteDouble(table.Name) + ")"); err != nil {
+		t.Fatal(err)
+	}
+
+	numDiffs, err = verify(converter, r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if numDiffs != 1 {
+		t.Errorf("expected 1 difference after deleting a row from %s but got %d", table.Name, numDiffs)
+	}
+}
+
+func TestVerifyColumnOrder(t *testing.T) {
+	// The primary key is not the first column.
+	table := &fdb.Table{Name: "Items", Columns: []*fdb.Column{{Variant: fdb.VariantNVarChar, Name: "name"}, {Variant: fdb.VariantI32, Name: "id"}}}
+	r := buildFdb(t, []*fdb.Table{table}, map[string][]fdb.Row{
+		"Items": {
+			{fdb.NewEntry(fdb.VariantNVarChar, "Brick"), fdb.NewEntry(fdb.VariantI32, int32(1))},
+			{fdb.NewEntry(fdb.VariantNVarChar, "Plate"), fdb.NewEntry(fdb.VariantI32, int32(2))},
+		},
+	})
+
+	db, converter := openSqlite(t)
+	for _, query := range []string{
+		`CREATE TABLE "Items" ("name" TEXT4, "id" INT32 PRIMARY KEY)`,
+		`INSERT INTO "Items" VALUES ('Brick', 1), ('Plate', 2)`,
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	numDiffs, err := verify(converter, r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if numDiffs != 0 {
+		t.Errorf("expected no differences but got %d", numDiffs)
+	}
+
+	// Verifying does not change how tables are converted to FDB.
+	tables, _, err := converter.Tables(TableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name := tables[0].Columns[0].Name; name != "id" {
+		t.Errorf("expected the primary key to be moved to the front but got %s", name)
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+type genColumn struct {
+	*fdb.Column
+
+	// The name of the struct field and the column index constant suffix.
+	Field string
+	Type  string
+}
+
+type genTable struct {
+	*fdb.Table
+
+	// The name of the row struct.
+	Type    string
+	Columns []genColumn
+
+	// The parameter name and methods used by Lookup<Type> and
+	// Get<Type>. Find and FindAll are empty if the table's key
+	// column cannot be looked up.
+	KeyParam string
+	Find     string
+	FindAll  string
+
+	// Whether every key of the table is unique, so Get<Type>
+	// is generated along with Lookup<Type>.
+	Unique bool
+}
+
+type genFile struct {
+	Package string
+	Source  string
+	Tables  []genTable
+}
+
+// The Go type of each variant's values, as returned by [fdb.Row.Value].
+var goTypes = map[fdb.Variant]string{
+	fdb.VariantNull:     "any",
+	fdb.VariantI32:      "int32",
+	fdb.VariantU32:      "uint32",
+	fdb.VariantReal:     "float32",
+	fdb.VariantNVarChar: "string",
+	fdb.VariantBool:     "bool",
+	fdb.VariantI64:      "int64",
+	fdb.VariantU64:      "uint64",
+	fdb.VariantText:     "string",
+}
+
+// Converts a table or column name into an exported Go identifier,
+// e.g. "LootTableIndex" and "loot_table_index" both become "LootTableIndex".
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	s := strings.Builder{}
+	for _, part := range parts {
+		runes := []rune(part)
+		s.WriteRune(unicode.ToUpper(runes[0]))
+		s.WriteString(string(runes[1:]))
+	}
+
+	ident := s.String()
+	if len(ident) == 0 || !unicode.IsLetter([]rune(ident)[0]) {
+		ident = "X" + ident
+	}
+
+	return ident
+}
+
+// The imported packages and unexported identifiers referenced
+// by the Lookup and Get methods, which their parameter must not shadow.
+var lookupIdents = []string{"fmt", "iter", "fdb", "db", "row", "err", "v", "value", "boolKey", "yield"}
+
+// Converts a column name into an unexported Go identifier usable as
+// the parameter of a Lookup method, which also refers to the scan
+// function of its table. Names which are keywords, predeclared, or
+// referenced by the method are suffixed with an underscore.
+func paramName(name, scan string) string {
+	runes := []rune(exportedName(name))
+	runes[0] = unicode.ToLower(runes[0])
+
+	param := string(runes)
+	if token.IsKeyword(param) || types.Universe.Lookup(param) != nil || slices.Contains(lookupIdents, param) || param == scan {
+		return param + "_"
+	}
+	return param
+}
+
+// Returns the [fdb.HashTable] method used to look up keys of the variant
+// and the conversion of the key parameter into the method's argument.
+// The method is prefixed with find, e.g. "Find" or "FindAll".
+func findMethod(variant fdb.Variant, find, param string) string {
+	switch variant {
+	case fdb.VariantI32, fdb.VariantI64:
+		return fmt.Sprintf("%sInt64(int64(%s))", find, param)
+	case fdb.VariantU32, fdb.VariantU64:
+		return fmt.Sprintf("%sUint64(uint64(%s))", find, param)
+	case fdb.VariantReal:
+		return fmt.Sprintf("%sFloat(%s)", find, param)
+	case fdb.VariantNVarChar, fdb.VariantText:
+		return fmt.Sprintf("%sString(%s)", find, param)
+	case fdb.VariantBool:
+		return fmt.Sprintf("%s(boolKey(%s))", find, param)
+	default:
+		return ""
+	}
+}
+
+// Keeps track of the generated top-level identifiers.
+type names map[string]string
+
+func (n names) add(ident, owner string) error {
+	if other, ok := n[ident]; ok {
+		return fmt.Errorf("%s: %s conflicts with %s", owner, ident, other)
+	}
+	n[ident] = owner
+	return nil
+}
+
+func newGenTable(table *fdb.Table, idents names, unique bool) (genTable, error) {
+	t := genTable{
+		Table:  table,
+		Type:   exportedName(table.Name),
+		Unique: unique,
+	}
+
+	for _, ident := range []string{t.Type, "Table" + t.Type, "Lookup" + t.Type, "Get" + t.Type, "All" + t.Type, "scan" + t.Type} {
+		if err := idents.add(ident, table.Name); err != nil {
+			return t, err
+		}
+	}
+
+	fields := map[string]bool{}
+	for _, column := range table.Columns {
+		goType, ok := goTypes[column.Variant]
+		if !ok {
+			return t, fmt.Errorf("%s.%s: unknown variant: %v", table.Name, column.Name, column.Variant)
+		}
+
+		field := exportedName(column.Name)
+		for i := 2; fields[field]; i++ {
+			field = fmt.Sprint(exportedName(column.Name), i)
+		}
+		fields[field] = true
+
+		if err := idents.add(t.Type+field, table.Name+"."+column.Name); err != nil {
+			return t, err
+		}
+
+		t.Columns = append(t.Columns, genColumn{Column: column, Field: field, Type: goType})
+	}
+
+	t.KeyParam = paramName(table.Columns[0].Name, "scan"+t.Type)
+	t.Find = findMethod(table.Columns[0].Variant, "Find", t.KeyParam)
+	t.FindAll = findMethod(table.Columns[0].Variant, "FindAll", t.KeyParam)
+
+	return t, nil
+}
+
+// Generates the accessors of the schema's tables. Get<Type> is
+// only generated for the tables named in unique, whose keys must
+// be known to be unique.
+func generate(pkg, source string, schema *fdb.Schema, unique []string) ([]byte, error) {
+	file := genFile{
+		Package: pkg,
+		Source:  source,
+	}
+
+	idents := names{}
+	for _, ident := range []string{"Schema", "Database", "New", "Open", "value", "boolKey"} {
+		idents.add(ident, "generated code")
+	}
+
+	for _, table := range schema.Tables {
+		if len(table.Columns) == 0 {
+			continue
+		}
+
+		t, err := newGenTable(table, idents, slices.Contains(unique, table.Name))
+		if err != nil {
+			return nil, err
+		}
+		file.Tables = append(file.Tables, t)
+	}
+
+	for _, name := range unique {
+		if !slices.ContainsFunc(file.Tables, func(t genTable) bool { return t.Name == name }) {
+			return nil, fmt.Errorf("unique: unknown table: %s", name)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := fileTemplate.Execute(&buf, file); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %v", err)
+	}
+
+	return src, nil
+}
+
+var fileTemplate = template.Must(template.New("file").Funcs(template.FuncMap{
+	"variant": func(v fdb.Variant) string {
+		return "fdb.Variant" + map[fdb.Variant]string{
+			fdb.VariantNull:     "Null",
+			fdb.VariantI32:      "I32",
+			fdb.VariantU32:      "U32",
+			fdb.VariantReal:     "Real",
+			fdb.VariantNVarChar: "NVarChar",
+			fdb.VariantBool:     "Bool",
+			fdb.VariantI64:      "I64",
+			fdb.VariantU64:      "U64",
+			fdb.VariantText:     "Text",
+		}[v]
+	},
+}).Parse(`// Code generated by gb-fdbgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"fmt"
+	"iter"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+// The tables and columns the accessors were generated from.
+var Schema = &fdb.Schema{Tables: []*fdb.Table{
+{{- range .Tables}}
+	{Name: {{printf "%q" .Name}}, Columns: []*fdb.Column{
+	{{- range .Columns}}
+		{Variant: {{variant .Variant}}, Name: {{printf "%q" .Name}}},
+	{{- end}}
+	}},
+{{- end}}
+}}
+
+// Wraps a [*fdb.Reader] whose tables match [Schema].
+type Database struct {
+	*fdb.Reader
+{{range .Tables}}
+	table{{.Type}} *fdb.Table
+{{- end}}
+}
+
+// Creates a [*Database] from r. New returns an error if any
+// table within [Schema] is missing from r or has different columns.
+// Tables which are not within [Schema] are ignored.
+func New(r *fdb.Reader) (*Database, error) {
+	tables := []*fdb.Table{}
+	for _, expected := range Schema.Tables {
+		if table, ok := r.FindTable(expected.Name); ok {
+			tables = append(tables, table)
+		}
+	}
+
+	errs, err := Schema.Validate(tables, nil)
+	if err != nil {
+		return nil, fmt.Errorf("{{.Package}}: %v", err)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("{{.Package}}: %w", errs[0])
+	}
+
+	db := &Database{Reader: r}
+{{- range .Tables}}
+	db.table{{.Type}}, _ = r.FindTable(Table{{.Type}})
+{{- end}}
+
+	return db, nil
+}
+
+// Opens the named FDB file. See [New].
+func Open(name string) (*Database, error) {
+	r, err := fdb.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("{{.Package}}: %v", err)
+	}
+
+	db, err := New(r)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Returns the value of the row's ith column. NULL entries
+// are returned as the zero value of T.
+func value[T any](row fdb.Row, i int) (T, error) {
+	var zero T
+
+	v, err := row.Value(i)
+	if err != nil || v == nil {
+		return zero, err
+	}
+
+	t, ok := v.(T)
+	if !ok {
+		return zero, fmt.Errorf("column %d: expected %T but got %T", i, zero, v)
+	}
+
+	return t, nil
+}
+
+func boolKey(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+{{range .Tables}}
+const Table{{.Type}} = {{printf "%q" .Name}}
+
+{{- $table := .}}
+
+// Column indices of the {{.Name}} table.
+const (
+{{- range $i, $column := .Columns}}
+	{{$table.Type}}{{.Field}} = {{$i}}
+{{- end}}
+)
+
+// A row of the {{.Name}} table. NULL entries are
+// represented by their column's zero value.
+type {{.Type}} struct {
+{{- range .Columns}}
+	{{.Field}} {{.Type}} // {{.Name}}
+{{- end}}
+}
+
+func scan{{.Type}}(row fdb.Row) (*{{.Type}}, error) {
+	if len(row) != {{len .Columns}} {
+		return nil, fmt.Errorf("expected {{len .Columns}} columns but got %d", len(row))
+	}
+
+	v := &{{.Type}}{}
+
+	var err error
+{{- range $i, $column := .Columns}}
+	if v.{{.Field}}, err = value[{{.Type}}](row, {{$i}}); err != nil {
+		return nil, err
+	}
+{{- end}}
+
+	return v, nil
+}
+{{if .Find}}
+// Returns every row of the {{.Name}} table whose {{(index .Columns 0).Name}}
+// is equal to {{.KeyParam}}.
+func (db *Database) Lookup{{.Type}}({{.KeyParam}} {{(index .Columns 0).Type}}) iter.Seq2[*{{.Type}}, error] {
+	return func(yield func(*{{.Type}}, error) bool) {
+		for row, err := range db.table{{.Type}}.HashTable().{{.FindAll}} {
+			if err != nil {
+				yield(nil, fmt.Errorf("{{$.Package}}: lookup {{.Name}}: %v", err))
+				return
+			}
+
+			v, err := scan{{.Type}}(row)
+			if err != nil {
+				yield(nil, fmt.Errorf("{{$.Package}}: lookup {{.Name}}: %v", err))
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+{{if .Unique}}
+// Returns the row of the {{.Name}} table whose {{(index .Columns 0).Name}}
+// is equal to {{.KeyParam}}. Every {{(index .Columns 0).Name}} of the table
+// is unique.
+func (db *Database) Get{{.Type}}({{.KeyParam}} {{(index .Columns 0).Type}}) (*{{.Type}}, error) {
+	row, err := db.table{{.Type}}.HashTable().{{.Find}}
+	if err != nil {
+		return nil, fmt.Errorf("{{$.Package}}: get {{.Name}}: %w", err)
+	}
+
+	v, err := scan{{.Type}}(row)
+	if err != nil {
+		return nil, fmt.Errorf("{{$.Package}}: get {{.Name}}: %v", err)
+	}
+
+	return v, nil
+}
+{{end}}{{end}}
+// Returns every row of the {{.Name}} table.
+func (db *Database) All{{.Type}}() iter.Seq2[*{{.Type}}, error] {
+	return func(yield func(*{{.Type}}, error) bool) {
+		for row, err := range db.table{{.Type}}.Rows() {
+			if err != nil {
+				yield(nil, fmt.Errorf("{{$.Package}}: all {{.Name}}: %v", err))
+				return
+			}
+
+			v, err := scan{{.Type}}(row)
+			if err != nil {
+				yield(nil, fmt.Errorf("{{$.Package}}: all {{.Name}}: %v", err))
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+{{end}}`))
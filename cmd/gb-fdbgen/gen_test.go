@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"iter"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+var update = flag.Bool("update", false, "Update the golden files.")
+
+func TestParamName(t *testing.T) {
+	tests := map[string]string{
+		"id":         "id",
+		"LootTable":  "lootTable",
+		"loot_table": "lootTable",
+		"type":       "type_",
+		"fmt":        "fmt_",
+		"iter":       "iter_",
+		"fdb":        "fdb_",
+		"int64":      "int64_",
+		"string":     "string_",
+		"nil":        "nil_",
+		"v":          "v_",
+		"err":        "err_",
+		"yield":      "yield_",
+		"scanItems":  "scanItems_",
+	}
+
+	for name, expected := range tests {
+		if actual := paramName(name, "scanItems"); actual != expected {
+			t.Errorf("%s: expected %s but got %s", name, expected, actual)
+		}
+	}
+}
+
+func generateFile(t *testing.T, name string, unique ...string) []byte {
+	schema, err := readSchema(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate("cdclient", filepath.Base(name), schema, unique)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src
+}
+
+func TestGenerate(t *testing.T) {
+	actual := generateFile(t, filepath.Join("testdata", "schema.json"), "Objects")
+
+	goldenName := filepath.Join("testdata", "cdclient.golden")
+	if *update {
+		if err := os.WriteFile(goldenName, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(goldenName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("generated code differs from %s; run go test with -update to update it", goldenName)
+	}
+
+	if _, err := generate("cdclient", "conflict", &fdb.Schema{Tables: []*fdb.Table{
+		{Name: "Items", Columns: []*fdb.Column{{Variant: fdb.VariantI32, Name: "id"}}},
+		{Name: "items", Columns: []*fdb.Column{{Variant: fdb.VariantI32, Name: "id"}}},
+	}}, nil); err == nil {
+		t.Error("expected conflicting table names to return an error")
+	}
+
+	if _, err := generate("cdclient", "unique", &fdb.Schema{Tables: []*fdb.Table{
+		{Name: "Items", Columns: []*fdb.Column{{Variant: fdb.VariantI32, Name: "id"}}},
+	}}, []string{"Objects"}); err == nil {
+		t.Error("expected unknown unique table to return an error")
+	}
+}
+
+// Builds and vets the code generated from the test schema
+// and from testdata/basic.fdb of the fdb package.
+func TestCompile(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	// Within the module, so the generated code can import the fdb package.
+	dir, err := os.MkdirTemp("testdata", "gen*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string][]string{
+		filepath.Join("testdata", "schema.json"):                              {"Objects"},
+		filepath.Join("..", "..", "database", "fdb", "testdata", "basic.fdb"): nil,
+	}
+
+	for name, unique := range tests {
+		t.Run(filepath.Base(name), func(t *testing.T) {
+			pkgDir := filepath.Join(dir, filepath.Base(name))
+			if err := os.Mkdir(pkgDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(pkgDir, "cdclient_gen.go"), generateFile(t, name, unique...), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, command := range []string{"build", "vet"} {
+				cmd := exec.Command(goBin, command, "./"+filepath.ToSlash(pkgDir))
+				if output, err := cmd.CombinedOutput(); err != nil {
+					t.Errorf("go %s: %v\n%s", command, err, output)
+				}
+			}
+		})
+	}
+}
+
+// Run within the package generated from testdata/schema.json,
+// against an FDB file with repeated ComponentsRegistry keys.
+const lookupTest = `package cdclient
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func TestLookup(t *testing.T) {
+	db, err := Open(os.Getenv("CDCLIENT_FDB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	components := []int32{}
+	for v, err := range db.LookupComponentsRegistry(1) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		components = append(components, v.ComponentType)
+	}
+
+	if len(components) != 3 || components[0] != 1 || components[1] != 2 || components[2] != 7 {
+		t.Errorf("expected component types [1 2 7] but got %v", components)
+	}
+
+	for range db.LookupComponentsRegistry(3) {
+		t.Error("expected no rows for a missing key")
+	}
+
+	object, err := db.GetObjects(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if object.Name != "Brick" {
+		t.Errorf("expected Brick but got %s", object.Name)
+	}
+
+	if _, err := db.GetObjects(3); !errors.Is(err, fdb.ErrRowNotFound) {
+		t.Errorf("expected %v but got %v", fdb.ErrRowNotFound, err)
+	}
+}
+`
+
+// Runs [lookupTest] against the code generated from the test schema.
+func TestLookup(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	dir, err := os.MkdirTemp("testdata", "gen*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schemaName := filepath.Join("testdata", "schema.json")
+	if err := os.WriteFile(filepath.Join(dir, "cdclient_gen.go"), generateFile(t, schemaName, "Objects"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "lookup_test.go"), []byte(lookupTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := readSchema(schemaName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := map[string][]fdb.Row{
+		"ComponentsRegistry": {
+			{fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantI32, int32(10))},
+			{fdb.NewEntry(fdb.VariantI32, int32(2)), fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantI32, int32(20))},
+			{fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantI32, int32(2)), fdb.NewEntry(fdb.VariantI32, int32(11))},
+			{fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantI32, int32(7)), fdb.NewEntry(fdb.VariantI32, int32(12))},
+		},
+		"Objects": {
+			{fdb.NewEntry(fdb.VariantI32, int32(1)), fdb.NewEntry(fdb.VariantNVarChar, "Brick"), fdb.NewEntry(fdb.VariantNull), fdb.NewEntry(fdb.VariantNull), fdb.NewEntry(fdb.VariantNull), fdb.NewEntry(fdb.VariantNull), fdb.NewEntry(fdb.VariantNull), fdb.NewEntry(fdb.VariantNull)},
+		},
+	}
+
+	fdbName, err := filepath.Abs(filepath.Join(dir, "cdclient.fdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := fdb.NewBuilder(file, schema.Tables)
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return func(yield func(fdb.Row, error) bool) {
+			for _, row := range rows[tableName] {
+				if !yield(row, nil) {
+					return
+				}
+			}
+		}
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	cmd := exec.Command(goBin, "test", "./"+filepath.ToSlash(dir))
+	cmd.Env = append(os.Environ(), "CDCLIENT_FDB="+fdbName)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+var Error = log.New(os.Stderr, "gb-fdbgen: ", 0)
+
+const Usage = `Usage:
+	gb-fdbgen [options] <fdb or JSON schema file>`
+
+// Reads the schema of an FDB file, or of a JSON schema
+// file exported by "goverbuild fdb schema".
+func readSchema(name string) (*fdb.Schema, error) {
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return fdb.ReadSchema(file)
+	}
+
+	r, err := fdb.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return fdb.SchemaOf(r), nil
+}
+
+func main() {
+	flagset := flag.NewFlagSet("gb-fdbgen", flag.ExitOnError)
+	pkg := flagset.String("pkg", os.Getenv("GOPACKAGE"), "The package name of the generated file. Defaults to $GOPACKAGE, as set by go generate.")
+	output := flagset.String("o", "", "The output file. If empty, the generated code is written to stdout.")
+	tables := flagset.String("tables", "", "A comma separated list of tables to generate accessors for. If empty, accessors are generated for every table.")
+	unique := flagset.String("unique", "", "A comma separated list of tables whose keys are unique. A Get<Table> method, which returns a single row, is generated for each of them.")
+	flagset.Usage = func() {
+		fmt.Println(Usage)
+		fmt.Println("\nOptions:")
+		flagset.PrintDefaults()
+	}
+	flagset.Parse(os.Args[1:])
+
+	input := flagset.Arg(0)
+	if len(input) == 0 {
+		flagset.Usage()
+		os.Exit(2)
+	}
+
+	if len(*pkg) == 0 {
+		*pkg = "cdclient"
+	}
+
+	schema, err := readSchema(input)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	if len(*tables) > 0 {
+		selected := &fdb.Schema{}
+		for _, name := range strings.Split(*tables, ",") {
+			table, ok := schema.FindTable(name)
+			if !ok {
+				Error.Fatalf("unknown table: %s", name)
+			}
+			selected.Tables = append(selected.Tables, table)
+		}
+		schema = selected
+	}
+
+	uniqueTables := []string{}
+	if len(*unique) > 0 {
+		uniqueTables = strings.Split(*unique, ",")
+	}
+
+	src, err := generate(*pkg, filepath.Base(input), schema, uniqueTables)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	if len(*output) == 0 {
+		os.Stdout.Write(src)
+		return
+	}
+
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		Error.Fatal(err)
+	}
+}
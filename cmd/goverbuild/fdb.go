@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"text/tabwriter"
@@ -157,23 +162,253 @@ func (c CsvTable) Flush() error {
 	return c.Writer.Error()
 }
 
-func fdbTables(args []string) {
-	flagset := flag.NewFlagSet("fdb:tables", flag.ExitOnError)
-	flagset.Parse(args)
+type JsonLinesTable struct {
+	*json.Encoder
+	Columns []*fdb.Column
+}
 
-	inputName := flagset.Arg(0)
-	if len(inputName) == 0 {
-		Error.Fatal("input name not provided")
+func NewJsonLinesTable(w io.Writer, columns []*fdb.Column) *JsonLinesTable {
+	return &JsonLinesTable{json.NewEncoder(w), columns}
+}
+
+func (t JsonLinesTable) Row(row fdb.Row) {
+	object := bytes.Buffer{}
+	object.WriteRune('{')
+	for i, column := range t.Columns {
+		v, err := row.Value(i)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		// JSON has no numbers for NaN or infinities.
+		if f, ok := v.(float32); ok && (math.IsNaN(float64(f)) || math.IsInf(float64(f), 0)) {
+			v = strconv.FormatFloat(float64(f), 'g', -1, 32)
+		}
+
+		key, _ := json.Marshal(column.Name)
+		value, err := json.Marshal(v)
+		if err != nil {
+			Error.Fatalf("%s: %v", column.Name, err)
+		}
+
+		if i > 0 {
+			object.WriteRune(',')
+		}
+		object.Write(key)
+		object.WriteRune(':')
+		object.Write(value)
+	}
+	object.WriteRune('}')
+
+	if err := t.Encode(json.RawMessage(object.Bytes())); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func (t JsonLinesTable) Flush() error {
+	return nil
+}
+
+// Writes a table as SQLite statements. Unsigned 64-bit values
+// are written by their int64 bits, the same way gb-fdb stores
+// them, and NaN and infinite reals are written as NULL.
+type SqlTable struct {
+	w       *bufio.Writer
+	Name    string
+	Columns []*fdb.Column
+
+	// The number of non-finite reals written as NULL, by column.
+	nonFinite map[string]int
+}
+
+func quoteSqlIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteSqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sqlColumnType(variant fdb.Variant) string {
+	switch variant {
+	case fdb.VariantI32, fdb.VariantU32, fdb.VariantBool, fdb.VariantI64, fdb.VariantU64:
+		return "INTEGER"
+	case fdb.VariantReal:
+		return "REAL"
+	case fdb.VariantNVarChar, fdb.VariantText:
+		return "TEXT"
+	default:
+		return "BLOB"
 	}
+}
+
+func NewSqlTable(w io.Writer, name string, columns []*fdb.Column) *SqlTable {
+	t := &SqlTable{bufio.NewWriter(w), name, columns, map[string]int{}}
+
+	fmt.Fprintf(t.w, "CREATE TABLE %s (", quoteSqlIdentifier(name))
+	for i, column := range columns {
+		if i > 0 {
+			t.w.WriteString(", ")
+		}
+		fmt.Fprintf(t.w, "%s %s", quoteSqlIdentifier(column.Name), sqlColumnType(column.Variant))
+	}
+	t.w.WriteString(");\n")
+
+	return t
+}
+
+func (t SqlTable) Row(row fdb.Row) {
+	fmt.Fprintf(t.w, "INSERT INTO %s VALUES (", quoteSqlIdentifier(t.Name))
+	for i := range t.Columns {
+		if i > 0 {
+			t.w.WriteString(", ")
+		}
+
+		v, err := row.Value(i)
+		if err != nil {
+			Error.Fatal(err)
+		}
 
-	db, err := fdb.OpenReader(inputName)
+		switch v := v.(type) {
+		case nil:
+			t.w.WriteString("NULL")
+		case string:
+			t.w.WriteString(quoteSqlString(v))
+		case bool:
+			if v {
+				t.w.WriteString("1")
+			} else {
+				t.w.WriteString("0")
+			}
+		case float32:
+			// SQL has no literals for NaN or infinities.
+			if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+				t.w.WriteString("NULL")
+				t.nonFinite[t.Columns[i].Name]++
+			} else {
+				t.w.WriteString(strconv.FormatFloat(float64(v), 'g', -1, 32))
+			}
+		case uint64:
+			// SQLite integers are signed.
+			fmt.Fprintf(t.w, "%d", int64(v))
+		default:
+			fmt.Fprintf(t.w, "%d", v)
+		}
+	}
+	t.w.WriteString(");\n")
+}
+
+func (t SqlTable) Flush() error {
+	for _, column := range t.Columns {
+		if n := t.nonFinite[column.Name]; n > 0 {
+			Error.Printf("warning: %s: %s: wrote %d NaN or infinite reals as NULL", t.Name, column.Name, n)
+		}
+	}
+	return t.w.Flush()
+}
+
+type MarkdownTable struct {
+	w       *bufio.Writer
+	Columns []*fdb.Column
+}
+
+var markdownReplacer = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+
+func NewMarkdownTable(w io.Writer, columns []*fdb.Column, withColumnTypes bool) *MarkdownTable {
+	t := &MarkdownTable{bufio.NewWriter(w), columns}
+
+	t.w.WriteString("|")
+	for _, column := range columns {
+		if withColumnTypes {
+			fmt.Fprintf(t.w, " %s[%s] |", markdownReplacer.Replace(column.Name), column.Variant)
+		} else {
+			fmt.Fprintf(t.w, " %s |", markdownReplacer.Replace(column.Name))
+		}
+	}
+	t.w.WriteString("\n|")
+	for range columns {
+		t.w.WriteString(" --- |")
+	}
+	t.w.WriteString("\n")
+
+	return t
+}
+
+func (t MarkdownTable) Row(row fdb.Row) {
+	t.w.WriteString("|")
+	for i := range t.Columns {
+		v, err := row.Value(i)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		switch v := v.(type) {
+		case nil:
+			t.w.WriteString(" [null] |")
+		case string:
+			fmt.Fprintf(t.w, " %s |", markdownReplacer.Replace(v))
+		default:
+			fmt.Fprintf(t.w, " %v |", v)
+		}
+	}
+	t.w.WriteString("\n")
+}
+
+func (t MarkdownTable) Flush() error {
+	return t.w.Flush()
+}
+
+// Maps each supported dump format to its file extension.
+var TableFormats = map[string]string{
+	"table": ".txt",
+	"csv":   ".csv",
+	"jsonl": ".jsonl",
+	"sql":   ".sql",
+	"md":    ".md",
+}
+
+const tableFormats = "table, csv, jsonl, sql, md"
+
+func NewTableWriter(format string, w io.Writer, table *fdb.Table, withHeader bool) (TableWriter, error) {
+	switch format {
+	case "table":
+		return NewFdbTable(w, table.Columns, withHeader), nil
+	case "csv":
+		return NewCsvTable(w, table.Columns, withHeader), nil
+	case "jsonl":
+		return NewJsonLinesTable(w, table.Columns), nil
+	case "sql":
+		return NewSqlTable(w, table.Name, table.Columns), nil
+	case "md":
+		return NewMarkdownTable(w, table.Columns, withHeader), nil
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func openFdb(path string) *fdb.Reader {
+	db, err := fdb.OpenReader(path)
 	if errors.Is(err, os.ErrNotExist) {
-		Error.Fatalf("fdb file does not exist: %s", inputName)
+		Error.Fatalf("fdb file does not exist: %s", path)
 	}
 
 	if err != nil {
 		Error.Fatal(err)
 	}
+
+	return db
+}
+
+func fdbTables(args []string) {
+	flagset := flag.NewFlagSet("fdb:tables", flag.ExitOnError)
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	db := openFdb(inputName)
 	defer db.Close()
 
 	for _, table := range db.Tables() {
@@ -184,10 +419,17 @@ func fdbTables(args []string) {
 func fdbDump(args []string) {
 	flagset := flag.NewFlagSet("fdb:dump", flag.ExitOnError)
 	withColumnTypes := flagset.Bool("colTypes", false, "Show type information next to column names.")
-	asCsv := flagset.Bool("csv", false, "Write table info as a csv.")
-	csvHeader := flagset.Bool("csvHeader", false, "Write column names as the first row of csv data.")
+	asCsv := flagset.Bool("csv", false, "Write table info as a csv, without the table name above the rows. Equivalent to -format csv.")
+	csvHeader := flagset.Bool("csvHeader", false, "Write column names as the first row of csv data, when -csv is set.")
+	format := flagset.String("format", "csv", "The output format. Supported formats: "+tableFormats)
 	flagset.Parse(args)
 
+	withHeader := *withColumnTypes
+	if *asCsv {
+		*format = "csv"
+		withHeader = *csvHeader
+	}
+
 	inputName := flagset.Arg(0)
 	if len(inputName) == 0 {
 		Error.Fatal("input name not provided")
@@ -198,14 +440,7 @@ func fdbDump(args []string) {
 		Error.Fatal("missing table name")
 	}
 
-	db, err := fdb.OpenReader(inputName)
-	if errors.Is(err, os.ErrNotExist) {
-		Error.Fatalf("fdb file does not exist: %s", inputName)
-	}
-
-	if err != nil {
-		Error.Fatal(err)
-	}
+	db := openFdb(inputName)
 	defer db.Close()
 
 	table, ok := db.FindTable(tableName)
@@ -213,11 +448,13 @@ func fdbDump(args []string) {
 		Error.Fatalf("table does not exist: %s", tableName)
 	}
 
-	var w TableWriter
-	if *asCsv {
-		w = NewCsvTable(os.Stdout, table.Columns, *csvHeader)
-	} else {
-		w = NewCsvTable(os.Stdout, table.Columns, *withColumnTypes)
+	if !*asCsv && (*format == "csv" || *format == "table") {
+		fmt.Fprintf(os.Stdout, "%s\n%s\n", table.Name, strings.Repeat("=", len(table.Name)))
+	}
+
+	w, err := NewTableWriter(*format, os.Stdout, table, withHeader)
+	if err != nil {
+		Error.Fatal(err)
 	}
 
 	for row, err := range table.Rows() {
@@ -228,15 +465,292 @@ func fdbDump(args []string) {
 		w.Row(row)
 	}
 
-	if !*asCsv {
-		fmt.Fprintf(os.Stdout, "%s\n%s\n", table.Name, strings.Repeat("=", len(table.Name)))
+	if err := w.Flush(); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func exportTable(dir, format string, table *fdb.Table, withHeader bool) error {
+	outputName := filepath.Join(dir, table.Name+TableFormats[format])
+	Verbose.Printf("%s -> %s", table.Name, outputName)
+
+	file, err := os.Create(outputName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := NewTableWriter(format, file, table, withHeader)
+	if err != nil {
+		return err
+	}
+
+	for row, err := range table.Rows() {
+		if err != nil {
+			return err
+		}
+
+		w.Row(row)
+	}
+
+	return w.Flush()
+}
+
+func fdbExportAll(args []string) {
+	flagset := flag.NewFlagSet("fdb:export-all", flag.ExitOnError)
+	flagset.BoolVar(&VerboseFlag, "v", false, "Enable verbose logging.")
+	output := flagset.String("o", "tables", "The directory to write each table to.")
+	format := flagset.String("format", "csv", "The output format. Supported formats: "+tableFormats)
+	withHeader := flagset.Bool("header", true, "Write column names as the first row of table, csv, and md data.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	if _, ok := TableFormats[*format]; !ok {
+		Error.Fatalf("unknown format: %s", *format)
+	}
+
+	db := openFdb(inputName)
+	defer db.Close()
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		Error.Fatal(err)
+	}
+
+	for _, table := range db.Tables() {
+		if err := exportTable(*output, *format, table, *withHeader); err != nil {
+			Error.Fatalf("%s: %v", table.Name, err)
+		}
+	}
+}
+
+func fdbDiff(args []string) {
+	flagset := flag.NewFlagSet("fdb:diff", flag.ExitOnError)
+	output := flagset.String("o", "", "Write the changeset to a file instead of stdout.")
+	summary := flagset.Bool("summary", false, "Only display a summary of the changed tables.")
+	flagset.Parse(args)
+
+	aName := flagset.Arg(0)
+	if len(aName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	bName := flagset.Arg(1)
+	if len(bName) == 0 {
+		Error.Fatal("second input name not provided")
+	}
+
+	a := openFdb(aName)
+	defer a.Close()
+
+	b := openFdb(bName)
+	defer b.Close()
+
+	changeset, err := fdb.Diff(a, b)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	if *summary {
+		tab := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tab, "table\taction\tremoved_rows\tadded_rows")
+		for _, table := range changeset.Tables {
+			fmt.Fprintf(tab, "%s\t%s\t%d\t%d\n", table.Name, table.Action, len(table.RemovedRows), len(table.AddedRows))
+			for _, column := range table.ColumnChanges {
+				if column.Action == fdb.ColumnRetyped {
+					fmt.Fprintf(tab, "  %s\t%s column\t%s -> %s\t\n", column.Name, column.Action, column.From, column.To)
+				} else {
+					fmt.Fprintf(tab, "  %s\t%s column\t\t\n", column.Name, column.Action)
+				}
+			}
+		}
+		tab.Flush()
+		return
+	}
+
+	w := io.Writer(os.Stdout)
+	if len(*output) > 0 {
+		file, err := os.Create(GetOutputName(*output, "changeset.json"))
+		if err != nil {
+			Error.Fatal(err)
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if err := fdb.WriteChangeset(w, changeset); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func fdbPatch(args []string) {
+	flagset := flag.NewFlagSet("fdb:patch", flag.ExitOnError)
+	output := flagset.String("o", "", "Sets the output path. If this option is not specified, the output name is the input name suffixed with '_patched'.")
+	maxBufferedRows := flagset.Int("maxBufferedRows", 0, "The maximum number of rows per changed table to hold in memory while writing its hash table. If the value is <= 0, every table is held in memory.")
+	sortBuckets := flagset.Bool("sortBuckets", false, "Sort the rows within each bucket of the changed tables by their key, similar to the files shipped with the client.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	changesetName := flagset.Arg(1)
+	if len(changesetName) == 0 {
+		Error.Fatal("changeset name not provided")
+	}
+
+	db := openFdb(inputName)
+	defer db.Close()
+
+	changesetFile, err := os.Open(changesetName)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer changesetFile.Close()
+
+	changeset, err := fdb.ReadChangeset(changesetFile)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	outputName := GetOutputName(*output, strings.TrimSuffix(filepath.Base(inputName), ".fdb")+"_patched.fdb")
+
+	outputFile, err := os.Create(outputName)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer outputFile.Close()
+
+	if err := fdb.Apply(db, changeset, outputFile, fdb.BuilderOptions{
+		MaxBufferedRows: *maxBufferedRows,
+		SortBuckets:     *sortBuckets,
+	}); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func fdbSchema(args []string) {
+	flagset := flag.NewFlagSet("fdb:schema", flag.ExitOnError)
+	output := flagset.String("o", "", "Write the schema to a file instead of stdout.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	db := openFdb(inputName)
+	defer db.Close()
+
+	w := io.Writer(os.Stdout)
+	if len(*output) > 0 {
+		file, err := os.Create(GetOutputName(*output, "schema.json"))
+		if err != nil {
+			Error.Fatal(err)
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	if err := fdb.WriteSchema(w, fdb.SchemaOf(db)); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func fdbValidate(args []string) {
+	flagset := flag.NewFlagSet("fdb:validate", flag.ExitOnError)
+	schemaName := flagset.String("schema", "", "The JSON schema file to validate against.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	if len(*schemaName) == 0 {
+		Error.Fatal("schema file not provided")
+	}
+
+	schemaFile, err := os.Open(*schemaName)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer schemaFile.Close()
+
+	schema, err := fdb.ReadSchema(schemaFile)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	db := openFdb(inputName)
+	defer db.Close()
+
+	errs, err := schema.ValidateReader(db)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+func fdbStats(args []string) {
+	flagset := flag.NewFlagSet("fdb:stats", flag.ExitOnError)
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	db := openFdb(inputName)
+	defer db.Close()
+
+	tables := db.Tables()
+	if flagset.NArg() > 1 {
+		tables = []*fdb.Table{}
+		for _, name := range flagset.Args()[1:] {
+			table, ok := db.FindTable(name)
+			if !ok {
+				Error.Fatalf("table does not exist: %s", name)
+			}
+			tables = append(tables, table)
+		}
+	}
+
+	tab := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tab, "table\trows\tbuckets\tload_factor\tlongest_chain\tnull_buckets")
+	for _, table := range tables {
+		stats, err := table.Stats()
+		if err != nil {
+			Error.Fatalf("%s: %v", table.Name, err)
+		}
+
+		fmt.Fprintf(tab, "%s\t%d\t%d\t%.2f\t%d\t%d\n", stats.Name, stats.Rows, stats.Buckets, stats.LoadFactor(), stats.LongestChain, stats.NullBuckets)
 	}
-	w.Flush()
+	tab.Flush()
 }
 
 var FdbCommands = CommandList{
-	"tables": fdbTables,
-	"dump":   fdbDump,
+	"tables":     fdbTables,
+	"dump":       fdbDump,
+	"export-all": fdbExportAll,
+	"diff":       fdbDiff,
+	"patch":      fdbPatch,
+	"schema":     fdbSchema,
+	"validate":   fdbValidate,
+	"stats":      fdbStats,
 }
 
 func doFdb(args []string) {
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/I-Am-Dench/goverbuild/encoding/ldf"
+)
+
+// A single LDF token as JSON. Values are JSON numbers and
+// booleans where possible, and strings otherwise.
+type LdfJsonToken struct {
+	Key   string          `json:"key"`
+	Type  ldf.ValueType   `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+func NewLdfJsonToken(token ldf.Token) (LdfJsonToken, error) {
+	jsonToken := LdfJsonToken{Key: token.Key, Type: token.Type}
+
+	switch token.Type {
+	case ldf.ValueTypeI32, ldf.ValueTypeFloat, ldf.ValueTypeDouble, ldf.ValueTypeU32, ldf.ValueTypeU64, ldf.ValueTypeI64:
+		if _, err := token.Interface(); err == nil && json.Valid(token.Value) {
+			jsonToken.Value = bytes.Clone(token.Value)
+			return jsonToken, nil
+		}
+	case ldf.ValueTypeBool:
+		switch string(token.Value) {
+		case "0":
+			jsonToken.Value = json.RawMessage("false")
+			return jsonToken, nil
+		case "1":
+			jsonToken.Value = json.RawMessage("true")
+			return jsonToken, nil
+		}
+	}
+
+	value, err := json.Marshal(string(token.Value))
+	if err != nil {
+		return jsonToken, err
+	}
+	jsonToken.Value = value
+
+	return jsonToken, nil
+}
+
+func (t LdfJsonToken) Token() (ldf.Token, error) {
+	token := ldf.Token{Key: t.Key, Type: t.Type}
+
+	switch value := bytes.TrimSpace(t.Value); {
+	case bytes.HasPrefix(value, []byte(`"`)):
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return token, fmt.Errorf("%s: %v", t.Key, err)
+		}
+		token.Value = []byte(s)
+	case bytes.Equal(value, []byte("true")):
+		token.Value = []byte("1")
+	case bytes.Equal(value, []byte("false")):
+		token.Value = []byte("0")
+	default:
+		token.Value = value
+	}
+
+	if _, err := token.Interface(); err != nil {
+		return token, fmt.Errorf("%s: %v", t.Key, err)
+	}
+
+	return token, nil
+}
+
+func readLdfDocument(name string) (*ldf.Document, os.FileMode) {
+	stat, err := os.Stat(name)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	doc := &ldf.Document{}
+	if err := ldf.UnmarshalText(data, doc); err != nil {
+		Error.Fatalf("%s: %v", name, err)
+	}
+
+	return doc, stat.Mode().Perm()
+}
+
+func writeLdfDocument(name string, doc *ldf.Document, perm os.FileMode) {
+	data, err := ldf.MarshalText(doc)
+	if err != nil {
+		Error.Fatal(err)
+	}
+
+	if err := os.WriteFile(name, data, perm); err != nil {
+		Error.Fatal(err)
+	}
+}
+
+func ldfGet(args []string) {
+	flagset := flag.NewFlagSet("ldf:get", flag.ExitOnError)
+	withType := flagset.Bool("t", false, "Prints the value prefixed with its value type, e.g. '1:80'.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	key := flagset.Arg(1)
+	if len(key) == 0 {
+		Error.Fatal("key not provided")
+	}
+
+	doc, _ := readLdfDocument(inputName)
+
+	token, ok := doc.Get(key)
+	if !ok {
+		Error.Fatalf("key does not exist: %s", key)
+	}
+
+	if *withType {
+		fmt.Printf("%d:%s\n", token.Type, token.Value)
+	} else {
+		fmt.Printf("%s\n", token.Value)
+	}
+}
+
+func ldfSet(args []string) {
+	flagset := flag.NewFlagSet("ldf:set", flag.ExitOnError)
+	valueType := flagset.Int("type", -1, "Sets the value type. If this option is not specified, the key's current value type is kept, or 0 (String) for new keys.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	key := flagset.Arg(1)
+	if len(key) == 0 {
+		Error.Fatal("key not provided")
+	}
+
+	if flagset.NArg() < 3 {
+		Error.Fatal("value not provided")
+	}
+
+	doc, perm := readLdfDocument(inputName)
+
+	value := ldf.RawValue{Type: ldf.ValueTypeString, Value: []byte(flagset.Arg(2))}
+	if token, ok := doc.Get(key); ok {
+		value.Type = token.Type
+	}
+
+	if *valueType >= 0 {
+		if *valueType > int(ldf.ValueTypeUtf8) {
+			Error.Fatalf("invalid value type: %d", *valueType)
+		}
+		value.Type = ldf.ValueType(*valueType)
+	}
+
+	if _, err := (ldf.Token{Key: key, Type: value.Type, Value: value.Value}).Interface(); err != nil {
+		Error.Fatalf("%s: invalid %v value: %v", key, value.Type, err)
+	}
+
+	if err := doc.Set(key, value); err != nil {
+		Error.Fatal(err)
+	}
+
+	writeLdfDocument(inputName, doc, perm)
+}
+
+func ldfDel(args []string) {
+	flagset := flag.NewFlagSet("ldf:del", flag.ExitOnError)
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	if flagset.NArg() < 2 {
+		Error.Fatal("key not provided")
+	}
+
+	doc, perm := readLdfDocument(inputName)
+
+	for _, key := range flagset.Args()[1:] {
+		if !doc.Delete(key) {
+			Error.Fatalf("key does not exist: %s", key)
+		}
+	}
+
+	writeLdfDocument(inputName, doc, perm)
+}
+
+func readLdf(r io.Reader, format string, compress bool) *ldf.Document {
+	doc := &ldf.Document{}
+
+	switch format {
+	case "text":
+		if err := ldf.NewTextDecoder(r).Decode(doc); err != nil {
+			Error.Fatal(err)
+		}
+	case "binary":
+		decoder := ldf.NewBinaryDecoder(r)
+		if compress {
+			decoder.UseCompression()
+		}
+
+		if err := decoder.Decode(doc); err != nil {
+			Error.Fatal(err)
+		}
+	case "json":
+		jsonTokens := []LdfJsonToken{}
+		if err := json.NewDecoder(r).Decode(&jsonTokens); err != nil {
+			Error.Fatal(err)
+		}
+
+		for _, jsonToken := range jsonTokens {
+			token, err := jsonToken.Token()
+			if err != nil {
+				Error.Fatal(err)
+			}
+			doc.Tokens = append(doc.Tokens, token)
+		}
+	default:
+		Error.Fatalf("unknown format: %s", format)
+	}
+
+	return doc
+}
+
+func writeLdf(w io.Writer, doc *ldf.Document, format string, compress bool) {
+	switch format {
+	case "text":
+		if err := ldf.NewTextEncoder(w).Encode(doc); err != nil {
+			Error.Fatal(err)
+		}
+	case "binary":
+		encoder := ldf.NewBinaryEncoder(w)
+		if compress {
+			encoder.UseCompression()
+		}
+
+		if err := encoder.Encode(doc); err != nil {
+			Error.Fatal(err)
+		}
+	case "json":
+		jsonTokens := []LdfJsonToken{}
+		for _, token := range doc.Tokens {
+			jsonToken, err := NewLdfJsonToken(token)
+			if err != nil {
+				Error.Fatal(err)
+			}
+			jsonTokens = append(jsonTokens, jsonToken)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(jsonTokens); err != nil {
+			Error.Fatal(err)
+		}
+	default:
+		Error.Fatalf("unknown format: %s", format)
+	}
+}
+
+func ldfConvert(args []string) {
+	flagset := flag.NewFlagSet("ldf:convert", flag.ExitOnError)
+	from := flagset.String("from", "text", "The input format: {text|binary|json}.")
+	to := flagset.String("to", "json", "The output format: {text|binary|json}.")
+	output := flagset.String("o", "", "Sets the output path. If this option is not specified, the output is written to stdout.")
+	compress := flagset.Bool("compress", false, "Reads or writes compressed binary LDF.")
+	lines := flagset.Bool("lines", false, "Separates tokens with a comma and newline when converting to text. Text input keeps its own delimiters.")
+	flagset.Parse(args)
+
+	inputName := flagset.Arg(0)
+	if len(inputName) == 0 {
+		Error.Fatal("input name not provided")
+	}
+
+	inputFile, err := os.Open(inputName)
+	if err != nil {
+		Error.Fatal(err)
+	}
+	defer inputFile.Close()
+
+	doc := readLdf(inputFile, *from, *compress)
+
+	w := io.Writer(os.Stdout)
+	if len(*output) > 0 {
+		outputFile, err := os.Create(GetOutputName(*output, inputName))
+		if err != nil {
+			Error.Fatal(err)
+		}
+		defer outputFile.Close()
+
+		w = outputFile
+	}
+
+	if *lines {
+		doc.Delim = ",\n"
+	}
+	writeLdf(w, doc, *to, *compress)
+}
+
+func ldfValidate(args []string) {
+	flagset := flag.NewFlagSet("ldf:validate", flag.ExitOnError)
+	flagset.Parse(args)
+
+	if flagset.NArg() < 1 {
+		Error.Fatal("input name not provided")
+	}
+
+	invalid := false
+	for _, inputName := range flagset.Args() {
+		inputFile, err := os.Open(inputName)
+		if err != nil {
+			Error.Fatal(err)
+		}
+
+		// Decoding into a map checks every value, in addition to every token.
+		decoder := ldf.NewTextDecoder(inputFile)
+		decoder.UseLax()
+
+		err = decoder.Decode(ldf.Map{})
+		inputFile.Close()
+		if err != nil {
+			Error.Fatalf("%s: %v", inputName, err)
+		}
+
+		for _, decodeErr := range decoder.Errors() {
+			fmt.Printf("%s: %v\n", inputName, decodeErr)
+			invalid = true
+		}
+	}
+
+	if invalid {
+		os.Exit(1)
+	}
+}
+
+var LdfCommands = CommandList{
+	"get":      ldfGet,
+	"set":      ldfSet,
+	"del":      ldfDel,
+	"convert":  ldfConvert,
+	"validate": ldfValidate,
+}
+
+func doLdf(args []string) {
+	SetLogPrefix("goverbuild(ldf): ")
+
+	if len(args) < 1 {
+		LdfCommands.Usage()
+	}
+
+	command, ok := LdfCommands[args[0]]
+	if !ok {
+		LdfCommands.Usage()
+	}
+
+	command(args[1:])
+}
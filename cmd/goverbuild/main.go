@@ -37,6 +37,7 @@ var Commands = CommandList{
 	"cache":     doCache,
 	"segmented": doSegmented,
 	"fdb":       doFdb,
+	"ldf":       doLdf,
 }
 
 func main() {
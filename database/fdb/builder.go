@@ -20,28 +20,113 @@ type writer = deferredwriter.Writer
 // yield the error and then stop the sequence.
 type RowsFunc = func(tableName string) iter.Seq2[Row, error]
 
+// A function that writes the hash table of the provided table.
+type hashTableFunc = func(w *writer, table *Table) error
+
+type BuilderOptions struct {
+	// The maximum number of rows per table the builder will
+	// hold in memory. When a table contains more rows, the
+	// builder iterates the table's rows a second time and writes
+	// each row as it is received.
+	//
+	// The number of buckets depends on the number of unique keys,
+	// so the first iteration counts them while holding at most
+	// MaxBufferedRows keys in memory, spilling the rest to a
+	// temporary file. The second iteration keeps 4 bytes per
+	// bucket for the end of each bucket's list.
+	//
+	// MaxBufferedRows also limits the number of strings, and of
+	// 64-bit integers, kept for sharing identical values across the
+	// file. See [BuildStats].
+	//
+	// Tables are always fully loaded into memory, and every
+	// value is shared, when MaxBufferedRows is <= 0.
+	MaxBufferedRows int
+
+	// The directory of the temporary files created when
+	// MaxBufferedRows is > 0. The default directory for
+	// temporary files is used when TempDir is empty.
+	// See [os.TempDir].
+	TempDir string
+
+	// Sorts the rows within each bucket by their key, similar to
+	// the files shipped with the client, which were converted from
+	// tables ordered by their first column. Rows with equal keys keep
+	// the order in which they are received. When false, rows are
+	// linked in the order in which they are received.
+	//
+	// SortBuckets does not apply to tables that are written
+	// as they are received. See MaxBufferedRows.
+	SortBuckets bool
+
+	// Returns the number of buckets of the table's hash table given
+	// the number of unique keys within the table. More buckets means
+	// shorter lists for [HashTable.Find] to walk, at the cost of 4 bytes
+	// per bucket. The count does not need to be a power of 2.
+	//
+	// The smallest power of 2 >= numKeys is used when NumBuckets
+	// is nil or returns 0.
+	NumBuckets func(table *Table, numKeys int) int
+}
+
+// Describes the file written by [Builder.Flush].
+//
+// Identical strings, and identical 64-bit integers, are only written
+// once, and every entry that contains the value points at the same
+// copy. The client only follows these pointers, so shared values
+// are read like any other value. When [BuilderOptions.MaxBufferedRows]
+// is > 0, only the first MaxBufferedRows unique values of each kind
+// are shared.
+type BuildStats struct {
+	// The total number of bytes written.
+	Size int64
+
+	Strings       int
+	UniqueStrings int
+	Int64s        int
+	UniqueInt64s  int
+
+	// The number of bytes not written because of shared values.
+	BytesSaved int64
+}
+
 // File type: [fdb]
 //
 // [fdb]: https://docs.lu-dev.net/en/latest/file-structures/database.html
 type Builder struct {
 	w      io.WriteSeeker
 	tables []*Table
+
+	options BuilderOptions
+	stats   *BuildStats
 }
 
 // Creates a [*Builder] with the provided [io.WriteSeeker]
 // and list of [*Table]'s. The provided tables are lexographically
 // sorted by their names.
-func NewBuilder(w io.WriteSeeker, tables []*Table) *Builder {
+func NewBuilder(w io.WriteSeeker, tables []*Table, options ...BuilderOptions) *Builder {
 	slices.SortFunc(tables, func(a, b *Table) int {
 		return strings.Compare(a.Name, b.Name)
 	})
 
+	o := BuilderOptions{}
+	if len(options) > 0 {
+		o = options[0]
+	}
+
 	return &Builder{
-		w:      w,
-		tables: tables,
+		w:       w,
+		tables:  tables,
+		options: o,
+		stats:   &BuildStats{},
 	}
 }
 
+// Returns the [BuildStats] of the last call to [Builder.Flush].
+func (b Builder) Stats() BuildStats {
+	return *b.stats
+}
+
 func (b Builder) writeDescription(w *writer, table *Table) (err error) {
 	defer func() {
 		if err == nil {
@@ -74,35 +159,71 @@ func (b Builder) writeDescription(w *writer, table *Table) (err error) {
 	return nil
 }
 
-func (b Builder) collectBuckets(table *Table, f RowsFunc) ([][]Row, error) {
+func bucketKey(table *Table, row Row) (uint32, error) {
+	if len(table.Columns) != len(row) {
+		return 0, fmt.Errorf("%s: mismatched columns: expected %d columns but got %d", table.Name, len(table.Columns), len(row))
+	}
+
+	return row.Key()
+}
+
+// Returns the number of buckets for a table with numKeys unique keys.
+func (b Builder) numBuckets(table *Table, numKeys int) (int, error) {
+	if b.options.NumBuckets == nil {
+		return bitCeil(numKeys), nil
+	}
+
+	n := b.options.NumBuckets(table, numKeys)
+	switch {
+	case n == 0:
+		return bitCeil(numKeys), nil
+	case n < 0 || uint64(n) >= uint64(noData):
+		return 0, fmt.Errorf("%s: invalid number of buckets: %d", table.Name, n)
+	}
+	return n, nil
+}
+
+// Groups the rows into buckets by their key. Keys are placed into
+// their buckets in the order they are first received, or in ascending
+// order if sortKeys is true, so the same rows always produce the same
+// buckets.
+func collectBuckets(table *Table, rows iter.Seq2[Row, error], numBuckets func(table *Table, numKeys int) (int, error), sortKeys bool) ([][]Row, error) {
 	if len(table.Columns) == 0 {
 		return [][]Row{}, nil
 	}
 
+	keys := []uint32{}
 	rowsById := make(map[uint32][]Row)
 
-	for row, err := range f(table.Name) {
+	for row, err := range rows {
 		if err != nil {
 			return nil, err
 		}
 
-		if len(table.Columns) != len(row) {
-			return nil, fmt.Errorf("%s: mismatched columns: expected %d columns but got %d", table.Name, len(table.Columns), len(row))
-		}
-
-		key, err := row.Id()
+		key, err := bucketKey(table, row)
 		if err != nil {
 			return nil, err
 		}
 
-		rows := rowsById[uint32(key)]
-		rowsById[uint32(key)] = append(rows, row)
+		if _, ok := rowsById[key]; !ok {
+			keys = append(keys, key)
+		}
+		rowsById[key] = append(rowsById[key], row)
+	}
+
+	if sortKeys {
+		slices.Sort(keys)
 	}
 
-	buckets := make([][]Row, bitCeil(len(rowsById)))
-	for id, rows := range rowsById {
+	n, err := numBuckets(table, len(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([][]Row, n)
+	for _, id := range keys {
 		index := id % uint32(len(buckets))
-		buckets[index] = append(buckets[index], rows...)
+		buckets[index] = append(buckets[index], rowsById[id]...)
 	}
 
 	return buckets, nil
@@ -223,30 +344,138 @@ func (b Builder) writeBuckets(buckets [][]Row) deferredwriter.DeferredArrayFunc
 	}
 }
 
-func (b Builder) writeRows(w *writer, table *Table, rows RowsFunc) error {
-	buckets, err := b.collectBuckets(table, rows)
-	if err != nil {
+func (b Builder) writeHashTable(w *writer, buckets [][]Row) error {
+	if err := w.PutUint32(uint32(len(buckets))); err != nil {
 		return fmt.Errorf("buckets: %v", err)
 	}
 
-	if err := w.PutUint32(uint32(len(buckets))); err != nil {
+	if err := w.DeferredArray(len(buckets), b.writeBuckets(buckets), true, 0xff); err != nil {
 		return fmt.Errorf("buckets: %v", err)
 	}
 
-	if err := w.DeferredArray(len(buckets), b.writeBuckets(buckets), true, 0xff); err != nil {
+	return nil
+}
+
+// Writes the rows in the order they are received, linking
+// each row to the end of its bucket's list.
+func (b Builder) streamHashTable(w *writer, table *Table, numBuckets int, rows iter.Seq2[Row, error]) error {
+	if err := w.PutUint32(uint32(numBuckets)); err != nil {
 		return fmt.Errorf("buckets: %v", err)
 	}
 
+	if err := w.PutUint32(w.Pos() + 4); err != nil {
+		return fmt.Errorf("buckets: %v", err)
+	}
+
+	arrayPos := w.Pos()
+	if err := w.PutFilled(numBuckets, 0xff); err != nil {
+		return fmt.Errorf("buckets: %v", err)
+	}
+
+	tails := make([]uint32, numBuckets)
+	for i := range tails {
+		tails[i] = noData
+	}
+
+	for row, err := range rows {
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+
+		key, err := bucketKey(table, row)
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+
+		if numBuckets == 0 {
+			return fmt.Errorf("buckets: %s: rows changed between iterations", table.Name)
+		}
+		index := key % uint32(numBuckets)
+
+		nodePos := w.Pos()
+		if err := w.PutUint32(nodePos + 8); err != nil {
+			return fmt.Errorf("bucket: %v", err)
+		}
+
+		if err := w.PutUint32(noData); err != nil {
+			return fmt.Errorf("bucket: %v", err)
+		}
+
+		if err := b.writeRow(w, row); err != nil {
+			return fmt.Errorf("bucket: %v", err)
+		}
+
+		link := arrayPos + index*4
+		if tails[index] != noData {
+			link = tails[index] + 4
+		}
+
+		if err := w.PatchUint32(link, nodePos); err != nil {
+			return fmt.Errorf("bucket: %v", err)
+		}
+		tails[index] = nodePos
+	}
+
 	return nil
 }
 
-// Writes the table descriptions and rows to the
-// underlying [io.WriteSeeker].
-//
-// The first [Entry] in a [Row], returned by the [iter.Seq2]
-// of the provided [RowsFunc], MUST NOT have a variant
-// equal to [VariantNull].
-func (b Builder) Flush(rows RowsFunc) error {
+func (b Builder) writeRows(w *writer, table *Table, rows RowsFunc) error {
+	if len(table.Columns) == 0 || b.options.MaxBufferedRows <= 0 {
+		buckets, err := collectBuckets(table, rows(table.Name), b.numBuckets, b.options.SortBuckets)
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+		return b.writeHashTable(w, buckets)
+	}
+
+	keys := newKeyCounter(b.options.MaxBufferedRows, b.options.TempDir)
+	defer keys.Close()
+
+	buffered := []Row{}
+	for row, err := range rows(table.Name) {
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+
+		key, err := bucketKey(table, row)
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+
+		if err := keys.add(key); err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+
+		if buffered != nil {
+			buffered = append(buffered, row)
+			if len(buffered) > b.options.MaxBufferedRows {
+				buffered = nil
+			}
+		}
+	}
+
+	if buffered != nil {
+		buckets, err := collectBuckets(table, rowSeq(buffered), b.numBuckets, b.options.SortBuckets)
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+		return b.writeHashTable(w, buckets)
+	}
+
+	numKeys, err := keys.count()
+	if err != nil {
+		return fmt.Errorf("buckets: %v", err)
+	}
+
+	numBuckets, err := b.numBuckets(table, numKeys)
+	if err != nil {
+		return fmt.Errorf("buckets: %v", err)
+	}
+
+	return b.streamHashTable(w, table, numBuckets, rows(table.Name))
+}
+
+func (b Builder) flush(writeHashTable hashTableFunc) error {
 	n, err := b.w.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return err
@@ -258,6 +487,7 @@ func (b Builder) Flush(rows RowsFunc) error {
 	}
 
 	dw := deferredwriter.New(b.w, order, tableOffset)
+	dw.MaxInterned = b.options.MaxBufferedRows
 
 	writeDescription := true
 	if err := dw.DeferredArray(len(b.tables)*2, func(w *writer, i int) (bool, error) {
@@ -268,11 +498,39 @@ func (b Builder) Flush(rows RowsFunc) error {
 			return true, b.writeDescription(w, table)
 		} else {
 			writeDescription = true
-			return true, b.writeRows(w, table, rows)
+			return true, writeHashTable(w, table)
 		}
 	}, true); err != nil {
 		return fmt.Errorf("flush to: %v", err)
 	}
 
+	*b.stats = BuildStats{
+		Size:          int64(dw.Pos()) - n,
+		Strings:       dw.Interned.Strings,
+		UniqueStrings: dw.Interned.UniqueStrings,
+		Int64s:        dw.Interned.Int64s,
+		UniqueInt64s:  dw.Interned.UniqueInt64s,
+		BytesSaved:    dw.Interned.BytesSaved,
+	}
+
 	return nil
 }
+
+// Writes the table descriptions and rows to the
+// underlying [io.WriteSeeker].
+//
+// The first [Entry] in a [Row], returned by the [iter.Seq2]
+// of the provided [RowsFunc], MUST NOT have a variant
+// equal to [VariantNull].
+//
+// The output only depends on the tables and the order of the rows,
+// so identical input always produces an identical file.
+//
+// If [BuilderOptions.MaxBufferedRows] is > 0, the provided
+// [RowsFunc] may be called twice for the same table. Both
+// sequences must yield the same rows.
+func (b Builder) Flush(rows RowsFunc) error {
+	return b.flush(func(w *writer, table *Table) error {
+		return b.writeRows(w, table, rows)
+	})
+}
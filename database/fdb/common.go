@@ -51,6 +51,26 @@ func (v Variant) String() string {
 	}
 }
 
+func (v Variant) MarshalText() ([]byte, error) {
+	switch v {
+	case VariantNull, VariantI32, VariantU32, VariantReal, VariantNVarChar, VariantBool, VariantI64, VariantU64, VariantText:
+		return []byte(v.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown variant: %d", v)
+	}
+}
+
+// Parses the variant's name as returned by [Variant.String].
+func (v *Variant) UnmarshalText(text []byte) error {
+	for variant := VariantNull; variant <= VariantText; variant++ {
+		if variant.String() == string(text) {
+			*v = variant
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown variant: %s", text)
+}
+
 func readNullTerminatedBytes(r io.Reader) ([]byte, error) {
 	c := make([]byte, 0, 32)
 	for {
@@ -0,0 +1,200 @@
+package fdb
+
+import (
+	"fmt"
+	"math"
+)
+
+// An FDB database held entirely in memory.
+//
+// A Database is an [*Editor] whose tables are all loaded
+// into memory, so it does not depend on any [*Reader] and
+// every table can be modified with the editor's methods.
+// Unlike [*Editor.Insert], rows are inserted as plain Go
+// values whose variants are inferred from the table's
+// columns. See [Database.Insert].
+//
+// A Database is written with [*Editor.Flush]. It does not have
+// a WriteTo method, since [io.WriterTo] takes an [io.Writer]
+// and FDB files can only be written to an [io.WriteSeeker].
+type Database struct {
+	*Editor
+}
+
+func NewDatabase() *Database {
+	return &Database{&Editor{}}
+}
+
+// Reads every table and row of the provided [*Reader] into
+// a [*Database]. The reader may be closed once Load returns.
+func Load(r *Reader) (*Database, error) {
+	e := Edit(r)
+	for _, t := range e.tables {
+		if err := t.materialize(); err != nil {
+			return nil, fmt.Errorf("fdb: load: %s: %v", t.table.Name, err)
+		}
+		t.source = nil
+	}
+
+	return &Database{e}, nil
+}
+
+func (db *Database) FindTable(name string) (*Table, bool) {
+	t, err := db.find(name)
+	if err != nil {
+		return nil, false
+	}
+	return t.table, true
+}
+
+// Returns an [Entry] of the column's variant containing
+// value. See [Database.Insert] for the accepted values.
+func columnEntry(column *Column, value any) (Entry, error) {
+	if value == nil {
+		return NewEntry(VariantNull), nil
+	}
+
+	if entry, ok := value.(Entry); ok {
+		if entry.Variant() != column.Variant && entry.Variant() != VariantNull {
+			return nil, fmt.Errorf("%w: cannot insert %v entry into %v column", ErrVariantMismatch, entry.Variant(), column.Variant)
+		}
+
+		// Entries from a Reader depend on the Reader remaining open.
+		return copyEntry(entry)
+	}
+
+	mismatch := fmt.Errorf("%w: cannot insert %T into %v column", ErrVariantMismatch, value, column.Variant)
+
+	switch column.Variant {
+	case VariantI32, VariantU32, VariantI64, VariantU64:
+		return integerEntry(column.Variant, value, mismatch)
+	case VariantReal:
+		switch v := value.(type) {
+		case float32:
+			return NewEntry(VariantReal, v), nil
+		case float64:
+			return NewEntry(VariantReal, float32(v)), nil
+		}
+	case VariantNVarChar, VariantText:
+		if v, ok := value.(string); ok {
+			return NewEntry(column.Variant, v), nil
+		}
+	case VariantBool:
+		if v, ok := value.(bool); ok {
+			return NewEntry(VariantBool, v), nil
+		}
+	}
+
+	return nil, mismatch
+}
+
+func integerEntry(variant Variant, value any, mismatch error) (Entry, error) {
+	var (
+		i        int64
+		u        uint64
+		unsigned bool
+	)
+
+	switch v := value.(type) {
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint:
+		u, unsigned = uint64(v), true
+	case uint8:
+		u, unsigned = uint64(v), true
+	case uint16:
+		u, unsigned = uint64(v), true
+	case uint32:
+		u, unsigned = uint64(v), true
+	case uint64:
+		u, unsigned = v, true
+	default:
+		return nil, mismatch
+	}
+
+	if !unsigned && i >= 0 {
+		u, unsigned = uint64(i), true
+	}
+
+	outOfRange := fmt.Errorf("%w: %v is out of range for %v column", ErrVariantMismatch, value, variant)
+
+	switch variant {
+	case VariantI32:
+		if (unsigned && u > math.MaxInt32) || (!unsigned && i < math.MinInt32) {
+			return nil, outOfRange
+		}
+		if unsigned {
+			return NewEntry(variant, int32(u)), nil
+		}
+		return NewEntry(variant, int32(i)), nil
+	case VariantU32:
+		if !unsigned || u > math.MaxUint32 {
+			return nil, outOfRange
+		}
+		return NewEntry(variant, uint32(u)), nil
+	case VariantI64:
+		if unsigned && u > math.MaxInt64 {
+			return nil, outOfRange
+		}
+		if unsigned {
+			return NewEntry(variant, int64(u)), nil
+		}
+		return NewEntry(variant, i), nil
+	default:
+		if !unsigned {
+			return nil, outOfRange
+		}
+		return NewEntry(variant, u), nil
+	}
+}
+
+// Appends a row containing the provided values to the named
+// table. Insert must be given one value per column, and each
+// value is converted to an [Entry] of its column's variant:
+//
+//   - nil is stored as a [VariantNull] entry.
+//   - An [Entry] must either have the column's variant or be [VariantNull].
+//     The entry is copied.
+//   - Any integer type is accepted by [VariantI32], [VariantU32],
+//     [VariantI64], and [VariantU64] columns, if the value fits.
+//   - float32 and float64 are accepted by [VariantReal] columns.
+//   - string is accepted by [VariantNVarChar] and [VariantText] columns.
+//   - bool is accepted by [VariantBool] columns.
+//
+// Any other value returns a wrapped [ErrVariantMismatch] error,
+// and the row is not inserted. Rows are hashed by their first
+// column, so a null first column returns a wrapped [ErrNullData]
+// error.
+func (db *Database) Insert(tableName string, values ...any) error {
+	table, ok := db.FindTable(tableName)
+	if !ok {
+		return fmt.Errorf("fdb: database: %s: %w", tableName, ErrTableNotFound)
+	}
+
+	if len(values) != len(table.Columns) {
+		return fmt.Errorf("fdb: database: %s: mismatched columns: expected %d columns but got %d", tableName, len(table.Columns), len(values))
+	}
+
+	row := make(Row, len(values))
+	for i, value := range values {
+		entry, err := columnEntry(table.Columns[i], value)
+		if err != nil {
+			return fmt.Errorf("fdb: database: %s.%s: %w", tableName, table.Columns[i].Name, err)
+		}
+		row[i] = entry
+	}
+
+	if row[0].Variant() == VariantNull {
+		return fmt.Errorf("fdb: database: %s.%s: key: %w", tableName, table.Columns[0].Name, ErrNullData)
+	}
+
+	return db.Editor.Insert(tableName, row)
+}
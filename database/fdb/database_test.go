@@ -0,0 +1,184 @@
+package fdb_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func flushDatabase(name string, db *fdb.Database) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return db.Flush(file)
+}
+
+func TestDatabase(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := fdb.NewDatabase()
+	if err := db.AddTable(&fdb.Table{Name: "Items", Columns: []*fdb.Column{
+		{fdb.VariantI32, "id"},
+		{fdb.VariantNVarChar, "name"},
+		{fdb.VariantReal, "weight"},
+		{fdb.VariantBool, "stackable"},
+		{fdb.VariantU64, "price"},
+		{fdb.VariantText, "description"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AddTable(&fdb.Table{Name: "Items"}); !errors.Is(err, fdb.ErrTableExists) {
+		t.Errorf("expected ErrTableExists but got: %v", err)
+	}
+
+	inserts := [][]any{
+		{1, "Sword", 2.5, false, uint64(math.MaxUint64), "A sword."},
+		{-2, "Shield", float32(4), true, 10, nil},
+		{int64(3), fdb.NewEntry(fdb.VariantNVarChar, "Bow"), 1.0, false, uint8(0), "A bow."},
+	}
+	for _, values := range inserts {
+		if err := db.Insert("Items", values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := map[string]struct {
+		values []any
+		err    error
+	}{
+		"missing values":   {[]any{4, "Axe"}, nil},
+		"string id":        {[]any{"4", "Axe", 1.0, false, 1, ""}, fdb.ErrVariantMismatch},
+		"int weight":       {[]any{4, "Axe", 1, false, 1, ""}, fdb.ErrVariantMismatch},
+		"i32 overflow":     {[]any{math.MaxInt32 + 1, "Axe", 1.0, false, 1, ""}, fdb.ErrVariantMismatch},
+		"negative u64":     {[]any{4, "Axe", 1.0, false, -1, ""}, fdb.ErrVariantMismatch},
+		"mismatched entry": {[]any{4, fdb.NewEntry(fdb.VariantText, "Axe"), 1.0, false, 1, ""}, fdb.ErrVariantMismatch},
+		"null key":         {[]any{nil, "Axe", 1.0, false, 1, ""}, fdb.ErrNullData},
+		"null key entry":   {[]any{fdb.NewEntry(fdb.VariantNull), "Axe", 1.0, false, 1, ""}, fdb.ErrNullData},
+	}
+	for name, test := range invalid {
+		err := db.Insert("Items", test.values...)
+		if err == nil || (test.err != nil && !errors.Is(err, test.err)) {
+			t.Errorf("%s: expected %v but got: %v", name, test.err, err)
+		}
+	}
+
+	if err := db.Insert("Missing", 1); !errors.Is(err, fdb.ErrTableNotFound) {
+		t.Errorf("expected ErrTableNotFound but got: %v", err)
+	}
+
+	expected := rowStrings(t, db.Rows("Items"))
+	if len(expected) != len(inserts) {
+		t.Fatalf("expected %d rows but got %d", len(inserts), len(expected))
+	}
+
+	fdbName := filepath.Join(dir, "database.fdb")
+	if err := flushDatabase(fdbName, db); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := fdb.OpenReader(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, ok := reader.FindTable("Items")
+	if !ok {
+		t.Fatal("missing table: Items")
+	}
+
+	actual := rowStrings(t, items.Rows())
+	slices.Sort(expected)
+	slices.Sort(actual)
+	if !slices.Equal(expected, actual) {
+		t.Errorf("expected rows %q but got %q", expected, actual)
+	}
+
+	row, err := items.HashTable().FindInt64(-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, _ := row[1].String(); name != "Shield" || row[5].Variant() != fdb.VariantNull {
+		t.Errorf("unexpected row: %v", row)
+	}
+
+	loaded, err := fdb.Load(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loaded.Insert("Items", 4, "Axe", 3.0, false, 25, "An axe."); err != nil {
+		t.Fatal(err)
+	}
+
+	// Loaded rows no longer depend on the closed reader.
+	if n, err := loaded.Delete("Items", func(row fdb.Row) bool {
+		return row[0].Int32() == 1
+	}); err != nil || n != 1 {
+		t.Fatalf("delete: expected 1 row but got %d: %v", n, err)
+	}
+
+	if n, err := loaded.Update("Items", func(row fdb.Row) bool {
+		return row[0].Int32() == 3
+	}, func(row fdb.Row) fdb.Row {
+		row[1] = fdb.NewEntry(fdb.VariantNVarChar, "Longbow")
+		return row
+	}); err != nil || n != 1 {
+		t.Fatalf("update: expected 1 row but got %d: %v", n, err)
+	}
+
+	if err := loaded.DropColumn("Items", "description"); err != nil {
+		t.Fatal(err)
+	}
+
+	loadedName := filepath.Join(dir, "loaded.fdb")
+	if err := flushDatabase(loadedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err = fdb.OpenReader(loadedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	items, _ = reader.FindTable("Items")
+	if rows := rowStrings(t, items.Rows()); len(rows) != len(inserts) {
+		t.Errorf("expected %d rows but got %d", len(inserts), len(rows))
+	}
+
+	if len(items.Columns) != 5 {
+		t.Errorf("expected 5 columns but got %d", len(items.Columns))
+	}
+
+	if _, err := items.HashTable().FindInt64(4); err != nil {
+		t.Errorf("find inserted row: %v", err)
+	}
+
+	if _, err := items.HashTable().FindInt64(1); !errors.Is(err, fdb.ErrRowNotFound) {
+		t.Errorf("expected ErrRowNotFound for deleted row but got: %v", err)
+	}
+
+	row, err = items.HashTable().FindInt64(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, _ := row[1].String(); name != "Longbow" {
+		t.Errorf("expected updated name %q but got %q", "Longbow", name)
+	}
+}
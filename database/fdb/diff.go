@@ -0,0 +1,407 @@
+package fdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+)
+
+type TableAction string
+
+const (
+	TableAdded    = TableAction("add")
+	TableRemoved  = TableAction("remove")
+	TableModified = TableAction("modify")
+
+	// The table's columns changed, so every row is replaced.
+	TableReplaced = TableAction("replace")
+)
+
+type ColumnAction string
+
+const (
+	ColumnAdded   = ColumnAction("add")
+	ColumnRemoved = ColumnAction("remove")
+	ColumnRetyped = ColumnAction("retype")
+	ColumnMoved   = ColumnAction("move")
+)
+
+type ColumnChange struct {
+	Action ColumnAction `json:"action"`
+	Name   string       `json:"name"`
+
+	// Only set when the column was retyped.
+	From *Variant `json:"from,omitempty"`
+	To   *Variant `json:"to,omitempty"`
+}
+
+type RowChange struct {
+	// The id of the row as returned by [Row.Id].
+	Id  int          `json:"id"`
+	Row []*DataEntry `json:"row"`
+}
+
+type TableChange struct {
+	Name   string      `json:"name"`
+	Action TableAction `json:"action"`
+
+	// The new columns of the table. Only set when the table
+	// is added or replaced.
+	Columns       []*Column      `json:"columns,omitempty"`
+	ColumnChanges []ColumnChange `json:"columnChanges,omitempty"`
+
+	RemovedRows []RowChange `json:"removedRows,omitempty"`
+	AddedRows   []RowChange `json:"addedRows,omitempty"`
+}
+
+// The set of changes required to turn one FDB file into
+// another. A Changeset can be encoded as JSON with
+// [WriteChangeset] and decoded with [ReadChangeset].
+type Changeset struct {
+	Tables []*TableChange `json:"tables"`
+}
+
+// Returns the number of rows removed and added across
+// every table.
+func (c Changeset) NumRows() (removed, added int) {
+	for _, table := range c.Tables {
+		removed += len(table.RemovedRows)
+		added += len(table.AddedRows)
+	}
+	return removed, added
+}
+
+// Returns a string that uniquely identifies the contents of a row.
+// Floats are identified by their bits, so NaNs with different
+// payloads are different rows.
+func rowKey(row Row) (string, error) {
+	key := strings.Builder{}
+	for i, entry := range row {
+		v, err := row.Value(i)
+		if err != nil {
+			return "", err
+		}
+
+		if f, ok := v.(float32); ok {
+			v = math.Float32bits(f)
+		}
+		fmt.Fprintf(&key, "%d:%v\x00", entry.Variant(), v)
+	}
+	return key.String(), nil
+}
+
+func toRowChange(row Row) (RowChange, error) {
+	id, err := row.Id()
+	if err != nil && !errors.Is(err, ErrNullData) {
+		return RowChange{}, err
+	}
+
+	c, err := row.copy()
+	if err != nil {
+		return RowChange{}, err
+	}
+
+	entries := make([]*DataEntry, len(c))
+	for i, entry := range c {
+		entries[i] = entry.(*DataEntry)
+	}
+
+	return RowChange{Id: id, Row: entries}, nil
+}
+
+func (c RowChange) row() Row {
+	row := make(Row, len(c.Row))
+	for i, entry := range c.Row {
+		row[i] = entry
+	}
+	return row
+}
+
+func diffColumns(a, b []*Column) []ColumnChange {
+	changes := []ColumnChange{}
+
+	for i, column := range a {
+		j := slices.IndexFunc(b, func(c *Column) bool { return c.Name == column.Name })
+		if j < 0 {
+			changes = append(changes, ColumnChange{Action: ColumnRemoved, Name: column.Name})
+			continue
+		}
+
+		if b[j].Variant != column.Variant {
+			changes = append(changes, ColumnChange{Action: ColumnRetyped, Name: column.Name, From: &column.Variant, To: &b[j].Variant})
+		}
+
+		if i != j {
+			changes = append(changes, ColumnChange{Action: ColumnMoved, Name: column.Name})
+		}
+	}
+
+	for _, column := range b {
+		if !slices.ContainsFunc(a, func(c *Column) bool { return c.Name == column.Name }) {
+			changes = append(changes, ColumnChange{Action: ColumnAdded, Name: column.Name})
+		}
+	}
+
+	return changes
+}
+
+func collectRowChanges(table *Table) ([]RowChange, error) {
+	changes := []RowChange{}
+	for row, err := range table.Rows() {
+		if err != nil {
+			return nil, err
+		}
+
+		change, err := toRowChange(row)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func diffRows(a, b *Table) (removed, added []RowChange, err error) {
+	counts := make(map[string]int)
+	for row, err := range b.Rows() {
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := rowKey(row)
+		if err != nil {
+			return nil, nil, err
+		}
+		counts[key]++
+	}
+
+	for row, err := range a.Rows() {
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := rowKey(row)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+
+		change, err := toRowChange(row)
+		if err != nil {
+			return nil, nil, err
+		}
+		removed = append(removed, change)
+	}
+
+	for row, err := range b.Rows() {
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := rowKey(row)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if counts[key] == 0 {
+			continue
+		}
+		counts[key]--
+
+		change, err := toRowChange(row)
+		if err != nil {
+			return nil, nil, err
+		}
+		added = append(added, change)
+	}
+
+	return removed, added, nil
+}
+
+func diffTable(a, b *Table) (*TableChange, error) {
+	if a == nil {
+		rows, err := collectRowChanges(b)
+		if err != nil {
+			return nil, err
+		}
+		return &TableChange{Name: b.Name, Action: TableAdded, Columns: b.Columns, AddedRows: rows}, nil
+	}
+
+	if b == nil {
+		return &TableChange{Name: a.Name, Action: TableRemoved}, nil
+	}
+
+	if columnChanges := diffColumns(a.Columns, b.Columns); len(columnChanges) > 0 {
+		rows, err := collectRowChanges(b)
+		if err != nil {
+			return nil, err
+		}
+		return &TableChange{Name: b.Name, Action: TableReplaced, Columns: b.Columns, ColumnChanges: columnChanges, AddedRows: rows}, nil
+	}
+
+	removed, added, err := diffRows(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(removed) == 0 && len(added) == 0 {
+		return nil, nil
+	}
+
+	return &TableChange{Name: b.Name, Action: TableModified, RemovedRows: removed, AddedRows: added}, nil
+}
+
+// Compares two FDB files table by table and returns the
+// changes required to turn a into b.
+//
+// Rows are compared by their full contents, so an updated
+// row is reported as a removed row and an added row with
+// the same id. Tables whose columns changed in any way are
+// reported as [TableReplaced] along with every row of the
+// new table.
+func Diff(a, b *Reader) (*Changeset, error) {
+	names := []string{}
+	for _, table := range a.Tables() {
+		names = append(names, table.Name)
+	}
+
+	for _, table := range b.Tables() {
+		if !slices.Contains(names, table.Name) {
+			names = append(names, table.Name)
+		}
+	}
+	slices.Sort(names)
+
+	changeset := &Changeset{Tables: []*TableChange{}}
+	for _, name := range names {
+		tableA, _ := a.FindTable(name)
+		tableB, _ := b.FindTable(name)
+
+		change, err := diffTable(tableA, tableB)
+		if err != nil {
+			return nil, fmt.Errorf("fdb: diff: %s: %v", name, err)
+		}
+
+		if change != nil {
+			changeset.Tables = append(changeset.Tables, change)
+		}
+	}
+
+	return changeset, nil
+}
+
+func applyTable(e *Editor, change *TableChange) error {
+	switch change.Action {
+	case TableRemoved:
+		return e.DropTable(change.Name)
+	case TableReplaced:
+		if err := e.DropTable(change.Name); err != nil {
+			return err
+		}
+		fallthrough
+	case TableAdded:
+		columns := make([]*Column, len(change.Columns))
+		for i, column := range change.Columns {
+			c := *column
+			columns[i] = &c
+		}
+
+		if err := e.AddTable(&Table{Name: change.Name, Columns: columns}); err != nil {
+			return err
+		}
+	case TableModified:
+		counts := make(map[string]int)
+		for _, removed := range change.RemovedRows {
+			key, err := rowKey(removed.row())
+			if err != nil {
+				return err
+			}
+			counts[key]++
+		}
+
+		var keyErr error
+		if _, err := e.Delete(change.Name, func(row Row) bool {
+			key, err := rowKey(row)
+			if err != nil {
+				keyErr = err
+				return false
+			}
+
+			if counts[key] > 0 {
+				counts[key]--
+				return true
+			}
+			return false
+		}); err != nil {
+			return err
+		}
+
+		if keyErr != nil {
+			return keyErr
+		}
+
+		for _, n := range counts {
+			if n > 0 {
+				return fmt.Errorf("%s: removed row does not exist", change.Name)
+			}
+		}
+	default:
+		return fmt.Errorf("%s: unknown action: %s", change.Name, change.Action)
+	}
+
+	for _, added := range change.AddedRows {
+		if err := e.Insert(change.Name, added.row()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Applies the changeset to the tables of the provided [*Reader]
+// and writes the result to w. The options are applied to every
+// table changed by the changeset. See [Editor.Flush].
+//
+// Apply returns an error if the changeset was not created against
+// the same tables as the reader.
+func Apply(r *Reader, c *Changeset, w io.WriteSeeker, options ...BuilderOptions) error {
+	editor := Edit(r)
+	for _, change := range c.Tables {
+		if err := applyTable(editor, change); err != nil {
+			return fmt.Errorf("fdb: apply: %v", err)
+		}
+	}
+
+	if err := editor.Flush(w, options...); err != nil {
+		return fmt.Errorf("fdb: apply: %v", err)
+	}
+
+	return nil
+}
+
+// Reads a JSON encoded [*Changeset].
+func ReadChangeset(r io.Reader) (*Changeset, error) {
+	c := &Changeset{}
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, fmt.Errorf("fdb: read changeset: %v", err)
+	}
+	return c, nil
+}
+
+// Writes the [*Changeset] as indented JSON.
+func WriteChangeset(w io.Writer, c *Changeset) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("fdb: write changeset: %v", err)
+	}
+	return nil
+}
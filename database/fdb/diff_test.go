@@ -0,0 +1,302 @@
+package fdb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func TestDiff(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	aName := filepath.Join(dir, "a.fdb")
+	if err := createTable(aName, []*fdb.Table{
+		{Name: "Objects", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}}},
+		{Name: "Removed", Columns: []*fdb.Column{{fdb.VariantI32, "id"}}},
+		{Name: "Retyped", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantI32, "value"}}},
+	}, map[string][]fdb.Row{
+		"Objects": {
+			{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantNVarChar, "Brick")},
+			{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantNVarChar, "Plate")},
+			{entry(fdb.VariantI32, int32(3)), entry(fdb.VariantNVarChar, "Tile")},
+		},
+		"Removed": {{entry(fdb.VariantI32, int32(1))}},
+		"Retyped": {{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantI32, int32(5))}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	bName := filepath.Join(dir, "b.fdb")
+	if err := createTable(bName, []*fdb.Table{
+		{Name: "Objects", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}}},
+		{Name: "Added", Columns: []*fdb.Column{{fdb.VariantNVarChar, "key"}, {fdb.VariantU64, "value"}}},
+		{Name: "Retyped", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantI64, "value"}}},
+	}, map[string][]fdb.Row{
+		"Objects": {
+			{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantNVarChar, "Brick")},
+			{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantNVarChar, "Round Plate")},
+			{entry(fdb.VariantI32, int32(4)), entry(fdb.VariantNull, nil)},
+		},
+		"Added":   {{entry(fdb.VariantNVarChar, "max"), entry(fdb.VariantU64, uint64(18446744073709551615))}},
+		"Retyped": {{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantI64, int64(-9223372036854775808))}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := fdb.OpenReader(aName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	b, err := fdb.OpenReader(bName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	changeset, err := fdb.Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := map[string]fdb.TableAction{}
+	for _, table := range changeset.Tables {
+		actions[table.Name] = table.Action
+	}
+
+	expectedActions := map[string]fdb.TableAction{
+		"Added":   fdb.TableAdded,
+		"Objects": fdb.TableModified,
+		"Removed": fdb.TableRemoved,
+		"Retyped": fdb.TableReplaced,
+	}
+	for name, expected := range expectedActions {
+		if actions[name] != expected {
+			t.Errorf("%s: expected action %q but got %q", name, expected, actions[name])
+		}
+	}
+
+	for _, table := range changeset.Tables {
+		if table.Name != "Objects" {
+			continue
+		}
+
+		if len(table.RemovedRows) != 2 || len(table.AddedRows) != 2 {
+			t.Errorf("Objects: expected 2 removed and 2 added rows but got %d and %d", len(table.RemovedRows), len(table.AddedRows))
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := fdb.WriteChangeset(&buf, changeset); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := fdb.ReadChangeset(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patchedName := filepath.Join(dir, "patched.fdb")
+	file, err := os.Create(patchedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fdb.Apply(a, decoded, file); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	patched, err := fdb.OpenReader(patchedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer patched.Close()
+
+	remaining, err := fdb.Diff(patched, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(remaining.Tables) != 0 {
+		for _, table := range remaining.Tables {
+			t.Errorf("%s: unexpected %q after applying changeset", table.Name, table.Action)
+		}
+	}
+
+	// Applying the same changeset twice must fail since the
+	// removed rows no longer exist.
+	if err := fdb.Apply(patched, decoded, &nopWriteSeeker{}); err == nil {
+		t.Error("expected error when applying changeset to patched database")
+	}
+}
+
+func TestChangesetJSON(t *testing.T) {
+	null, i32 := fdb.VariantNull, fdb.VariantI32
+	data, err := json.Marshal(fdb.ColumnChange{Action: fdb.ColumnRetyped, Name: "value", From: &null, To: &i32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change := map[string]any{}
+	if err := json.Unmarshal(data, &change); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := change["from"]; !ok {
+		t.Errorf("expected a null variant to be encoded: %s", data)
+	}
+
+	data, err = json.Marshal(fdb.ColumnChange{Action: fdb.ColumnAdded, Name: "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	change = map[string]any{}
+	if err := json.Unmarshal(data, &change); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := change["from"]; ok {
+		t.Errorf("expected added column to have no variants: %s", data)
+	}
+
+	if _, ok := change["to"]; ok {
+		t.Errorf("expected added column to have no variants: %s", data)
+	}
+
+	for _, f := range []float32{float32(math.NaN()), math.Float32frombits(0x7fc00001), math.Float32frombits(0xffc00000), float32(math.Inf(1)), float32(math.Inf(-1)), 1.5} {
+		data, err := json.Marshal(fdb.NewEntry(fdb.VariantReal, f))
+		if err != nil {
+			t.Fatalf("%v: %v", f, err)
+		}
+
+		entry := fdb.DataEntry{}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+
+		if actual := entry.Float32(); math.Float32bits(actual) != math.Float32bits(f) {
+			t.Errorf("%s: expected %#x but got %#x", data, math.Float32bits(f), math.Float32bits(actual))
+		}
+	}
+}
+
+type nopWriteSeeker struct{}
+
+func (nopWriteSeeker) Write(p []byte) (int, error)                  { return len(p), nil }
+func (nopWriteSeeker) Seek(offset int64, whence int) (int64, error) { return 0, nil }
+
+func TestDiffNaN(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tables := func() []*fdb.Table {
+		return []*fdb.Table{{Name: "Reals", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantReal, "value"}}}}
+	}
+
+	aName := filepath.Join(dir, "a.fdb")
+	if err := createTable(aName, tables(), map[string][]fdb.Row{
+		"Reals": {
+			{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantReal, float32(math.NaN()))},
+			{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantReal, float32(1.5))},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the payload of the NaN differs.
+	bName := filepath.Join(dir, "b.fdb")
+	if err := createTable(bName, tables(), map[string][]fdb.Row{
+		"Reals": {
+			{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantReal, math.Float32frombits(0x7fc00001))},
+			{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantReal, float32(1.5))},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := fdb.OpenReader(aName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	b, err := fdb.OpenReader(bName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	changeset, err := fdb.Diff(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, added := changeset.NumRows(); removed != 1 || added != 1 {
+		t.Fatalf("expected 1 removed and 1 added row but got %d and %d", removed, added)
+	}
+
+	buf := bytes.Buffer{}
+	if err := fdb.WriteChangeset(&buf, changeset); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := fdb.ReadChangeset(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patchedName := filepath.Join(dir, "patched.fdb")
+	file, err := os.Create(patchedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changed tables are written with the builder options.
+	if err := fdb.Apply(a, decoded, file, fdb.BuilderOptions{
+		NumBuckets: func(table *fdb.Table, numKeys int) int { return 3 },
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	patched, err := fdb.OpenReader(patchedName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer patched.Close()
+
+	remaining, err := fdb.Diff(patched, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(remaining.Tables) != 0 {
+		t.Errorf("expected no changes after applying changeset but got %d tables", len(remaining.Tables))
+	}
+
+	stats, err := fdb.Stats(patched)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats[0].Buckets != 3 {
+		t.Errorf("expected 3 buckets but got %d", stats[0].Buckets)
+	}
+}
@@ -0,0 +1,379 @@
+package fdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+)
+
+var (
+	ErrTableNotFound  = errors.New("table not found")
+	ErrTableExists    = errors.New("table already exists")
+	ErrColumnNotFound = errors.New("column not found")
+	ErrColumnExists   = errors.New("column already exists")
+)
+
+type editTable struct {
+	table  *Table
+	source *Table
+
+	// Rows are only materialized once the table is modified.
+	modified bool
+	rows     []Row
+}
+
+func (t *editTable) materialize() error {
+	if t.modified {
+		return nil
+	}
+
+	rows := []Row{}
+	if t.source != nil {
+		for row, err := range t.source.Rows() {
+			if err != nil {
+				return err
+			}
+
+			c, err := row.copy()
+			if err != nil {
+				return err
+			}
+			rows = append(rows, c)
+		}
+	}
+
+	t.rows = rows
+	t.modified = true
+
+	return nil
+}
+
+// Checks that every entry has its column's variant or is null.
+func (t *editTable) validate(row Row) error {
+	if len(row) != len(t.table.Columns) {
+		return fmt.Errorf("%s: mismatched columns: expected %d columns but got %d", t.table.Name, len(t.table.Columns), len(row))
+	}
+
+	for i, entry := range row {
+		if err := validateEntry(t.table.Columns[i], entry); err != nil {
+			return fmt.Errorf("%s: %w", t.table.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateEntry(column *Column, entry Entry) error {
+	if entry.Variant() != column.Variant && entry.Variant() != VariantNull {
+		return fmt.Errorf("%s: %w: expected %v but got %v", column.Name, ErrVariantMismatch, column.Variant, entry.Variant())
+	}
+	return nil
+}
+
+func (t *editTable) columnIndex(name string) int {
+	return slices.IndexFunc(t.table.Columns, func(c *Column) bool {
+		return c.Name == name
+	})
+}
+
+// An editable view of an existing FDB file.
+//
+// Tables which are never modified keep their original
+// hash table layout and are copied byte for byte when
+// flushed, with only their pointers relocated. If an
+// unmodified table's bytes are not contiguous in the
+// source file, for example because its values are shared
+// with other tables, its hash table is re-encoded instead.
+// Tables which are modified are loaded into memory and
+// have their hash tables rebuilt when flushed.
+type Editor struct {
+	tables []*editTable
+}
+
+// Creates an [*Editor] with the tables from the provided [*Reader].
+// The reader must remain open until [*Editor.Flush] is called.
+func Edit(r *Reader) *Editor {
+	e := &Editor{}
+	for _, source := range r.Tables() {
+		columns := make([]*Column, len(source.Columns))
+		for i, column := range source.Columns {
+			c := *column
+			columns[i] = &c
+		}
+
+		e.tables = append(e.tables, &editTable{
+			table: &Table{
+				Name:    source.Name,
+				Columns: columns,
+			},
+			source: source,
+		})
+	}
+
+	return e
+}
+
+func (e *Editor) find(name string) (*editTable, error) {
+	for _, t := range e.tables {
+		if t.table.Name == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", name, ErrTableNotFound)
+}
+
+func (e *Editor) findModified(name string) (*editTable, error) {
+	t, err := e.find(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.materialize(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return t, nil
+}
+
+// Returns the current definitions of every table.
+func (e *Editor) Tables() []*Table {
+	tables := make([]*Table, len(e.tables))
+	for i, t := range e.tables {
+		tables[i] = t.table
+	}
+	return tables
+}
+
+// Returns the rows currently stored in the named table.
+func (e *Editor) Rows(tableName string) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		t, err := e.find(tableName)
+		if err != nil {
+			yield(nil, fmt.Errorf("fdb: edit: %w", err))
+			return
+		}
+
+		if !t.modified {
+			for row, err := range t.source.Rows() {
+				if !yield(row, err) || err != nil {
+					return
+				}
+			}
+			return
+		}
+
+		rowSeq(t.rows)(yield)
+	}
+}
+
+// Adds an empty table. AddTable returns a wrapped [ErrTableExists]
+// error if a table with the same name already exists.
+func (e *Editor) AddTable(table *Table) error {
+	if _, err := e.find(table.Name); err == nil {
+		return fmt.Errorf("fdb: edit: %s: %w", table.Name, ErrTableExists)
+	}
+
+	e.tables = append(e.tables, &editTable{
+		table:    table,
+		modified: true,
+		rows:     []Row{},
+	})
+	return nil
+}
+
+// Removes the named table.
+func (e *Editor) DropTable(name string) error {
+	i := slices.IndexFunc(e.tables, func(t *editTable) bool {
+		return t.table.Name == name
+	})
+	if i < 0 {
+		return fmt.Errorf("fdb: edit: %s: %w", name, ErrTableNotFound)
+	}
+
+	e.tables = slices.Delete(e.tables, i, i+1)
+	return nil
+}
+
+// Appends a column to the named table. Every existing
+// row is extended with a copy of value. If value is nil,
+// rows are extended with a [VariantNull] entry.
+func (e *Editor) AddColumn(tableName string, column *Column, value Entry) error {
+	t, err := e.findModified(tableName)
+	if err != nil {
+		return fmt.Errorf("fdb: edit: %w", err)
+	}
+
+	if t.columnIndex(column.Name) >= 0 {
+		return fmt.Errorf("fdb: edit: %s: %s: %w", tableName, column.Name, ErrColumnExists)
+	}
+
+	if value == nil {
+		value = NewEntry(VariantNull)
+	}
+
+	if err := validateEntry(column, value); err != nil {
+		return fmt.Errorf("fdb: edit: %s: %w", tableName, err)
+	}
+
+	for i, row := range t.rows {
+		entry, err := copyEntry(value)
+		if err != nil {
+			return fmt.Errorf("fdb: edit: %s: %s: %v", tableName, column.Name, err)
+		}
+		// Rows may share their backing array with rows
+		// passed to Insert, so they are never appended
+		// to in place.
+		t.rows[i] = append(slices.Clip(row), entry)
+	}
+
+	t.table.Columns = append(t.table.Columns, column)
+	return nil
+}
+
+// Removes the named column from the table and its rows.
+//
+// Removing the first column changes the key of every row.
+// Tables must always have at least one column.
+func (e *Editor) DropColumn(tableName, columnName string) error {
+	t, err := e.findModified(tableName)
+	if err != nil {
+		return fmt.Errorf("fdb: edit: %w", err)
+	}
+
+	i := t.columnIndex(columnName)
+	if i < 0 {
+		return fmt.Errorf("fdb: edit: %s: %s: %w", tableName, columnName, ErrColumnNotFound)
+	}
+
+	if len(t.table.Columns) == 1 {
+		return fmt.Errorf("fdb: edit: %s: cannot drop the only column", tableName)
+	}
+
+	for j, row := range t.rows {
+		t.rows[j] = slices.Concat(row[:i], row[i+1:])
+	}
+
+	t.table.Columns = slices.Delete(t.table.Columns, i, i+1)
+	return nil
+}
+
+// Appends the rows to the named table. Each row must have
+// the same number of entries as the table has columns, and
+// each entry must have its column's variant or be null.
+func (e *Editor) Insert(tableName string, rows ...Row) error {
+	t, err := e.findModified(tableName)
+	if err != nil {
+		return fmt.Errorf("fdb: edit: %w", err)
+	}
+
+	for _, row := range rows {
+		if err := t.validate(row); err != nil {
+			return fmt.Errorf("fdb: edit: %w", err)
+		}
+	}
+
+	t.rows = append(t.rows, rows...)
+	return nil
+}
+
+// Replaces every row in the named table for which match
+// returns true with the row returned by update. Update
+// returns the number of rows that were replaced.
+//
+// The rows passed to match and update are owned by the
+// editor and may be modified in place.
+func (e *Editor) Update(tableName string, match func(Row) bool, update func(Row) Row) (int, error) {
+	t, err := e.findModified(tableName)
+	if err != nil {
+		return 0, fmt.Errorf("fdb: edit: %w", err)
+	}
+
+	n := 0
+	for i, row := range t.rows {
+		if !match(row) {
+			continue
+		}
+
+		updated := update(row)
+		if err := t.validate(updated); err != nil {
+			return n, fmt.Errorf("fdb: edit: %w", err)
+		}
+
+		t.rows[i] = updated
+		n++
+	}
+
+	return n, nil
+}
+
+// Removes every row in the named table for which match
+// returns true. Delete returns the number of rows that
+// were removed.
+func (e *Editor) Delete(tableName string, match func(Row) bool) (int, error) {
+	t, err := e.findModified(tableName)
+	if err != nil {
+		return 0, fmt.Errorf("fdb: edit: %w", err)
+	}
+
+	n := len(t.rows)
+	t.rows = slices.DeleteFunc(t.rows, match)
+
+	return n - len(t.rows), nil
+}
+
+func rowSeq(rows []Row) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Writes the edited tables to the provided [io.WriteSeeker].
+//
+// The options are applied to the tables which were modified,
+// as if they were written by a [*Builder]. Unmodified tables
+// keep their original buckets.
+//
+// The provided writer MUST NOT be the file being edited.
+func (e *Editor) Flush(w io.WriteSeeker, options ...BuilderOptions) error {
+	builder := NewBuilder(w, e.Tables(), options...)
+	if err := builder.flush(func(w *writer, table *Table) error {
+		t, err := e.find(table.Name)
+		if err != nil {
+			return err
+		}
+
+		if t.modified {
+			return builder.writeRows(w, table, func(string) iter.Seq2[Row, error] {
+				return rowSeq(t.rows)
+			})
+		}
+
+		if t.source.HashTable() == nil {
+			return builder.writeHashTable(w, [][]Row{})
+		}
+
+		copied, err := copyHashTable(w, t.source.HashTable())
+		if err != nil {
+			return fmt.Errorf("%s: copy: %v", table.Name, err)
+		}
+
+		if copied {
+			return nil
+		}
+
+		buckets, err := t.source.HashTable().buckets()
+		if err != nil {
+			return fmt.Errorf("buckets: %v", err)
+		}
+		return builder.writeHashTable(w, buckets)
+	}); err != nil {
+		return fmt.Errorf("fdb: edit: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+package fdb_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func rowStrings(t *testing.T, rows iter.Seq2[fdb.Row, error]) []string {
+	s := []string{}
+	for row, err := range rows {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		values := make([]any, len(row))
+		for i := range row {
+			v, err := row.Value(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			values[i] = v
+		}
+		s = append(s, fmt.Sprint(values...))
+	}
+	return s
+}
+
+func checkRowStrings(t *testing.T, tableName string, expected []fdb.Row, actual iter.Seq2[fdb.Row, error]) {
+	expectedStrings := rowStrings(t, func(yield func(fdb.Row, error) bool) {
+		for _, row := range expected {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	})
+	actualStrings := rowStrings(t, actual)
+
+	slices.Sort(expectedStrings)
+	slices.Sort(actualStrings)
+
+	if !slices.Equal(expectedStrings, actualStrings) {
+		t.Errorf("%s:\nexpected = %v\nactual   = %v", tableName, expectedStrings, actualStrings)
+	}
+}
+
+// Returns the offset of the named table's bucket array and the
+// bytes from there until the next table description or the end
+// of the file.
+func hashTableBytes(t *testing.T, name, tableName string) (uint32, []byte) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u32 := func(offset uint32) uint32 {
+		return binary.LittleEndian.Uint32(data[offset:])
+	}
+
+	zstring := func(offset uint32) string {
+		end := offset
+		for data[end] != 0 {
+			end++
+		}
+		return string(data[offset:end])
+	}
+
+	numTables, tablesOffset := u32(0), u32(4)
+
+	base, end := uint32(0), uint32(len(data))
+	descriptions := []uint32{}
+	for i := range numTables {
+		description, hashTable := u32(tablesOffset+i*8), u32(tablesOffset+i*8+4)
+		descriptions = append(descriptions, description)
+
+		if zstring(u32(description+4)) == tableName {
+			base = u32(hashTable + 4)
+		}
+	}
+
+	if base == 0 {
+		t.Fatalf("could not find table %s", tableName)
+	}
+
+	for _, description := range descriptions {
+		if description > base && description < end {
+			end = description
+		}
+	}
+	return base, data[base:end]
+}
+
+func TestEdit(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tables := []*fdb.Table{
+		{Name: "Items", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}}},
+		{Name: "Untouched", Columns: []*fdb.Column{{fdb.VariantU32, "id"}, {fdb.VariantReal, "value"}, {fdb.VariantText, "text"}, {fdb.VariantI64, "i64"}}},
+		{Name: "Dropped", Columns: []*fdb.Column{{fdb.VariantI32, "id"}}},
+	}
+
+	untouched := make([]fdb.Row, 20)
+	for i := range untouched {
+		untouched[i] = createRow(tables[1].Columns)
+	}
+
+	// Re-encoding Untouched would share this string with Items.
+	untouched[0][2] = entry(fdb.VariantText, "Slope")
+
+	rows := map[string][]fdb.Row{
+		"Items": {
+			{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantNVarChar, "Brick")},
+			{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantNVarChar, "Plate")},
+			{entry(fdb.VariantI32, int32(3)), entry(fdb.VariantNVarChar, "Tile")},
+		},
+		"Untouched": untouched,
+		"Dropped": {
+			{entry(fdb.VariantI32, int32(1))},
+		},
+	}
+
+	inputName := filepath.Join(dir, "input.fdb")
+	if err := createTable(inputName, tables, rows); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := fdb.OpenReader(inputName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	editor := fdb.Edit(reader)
+
+	if err := editor.Insert("Items", fdb.Row{entry(fdb.VariantI32, int32(4)), entry(fdb.VariantNVarChar, "Slope")}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := editor.Update("Items", func(row fdb.Row) bool {
+		return row[0].Int32() == 2
+	}, func(row fdb.Row) fdb.Row {
+		row[1] = entry(fdb.VariantNVarChar, "Round Plate")
+		return row
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 updated row but got %d", n)
+	}
+
+	n, err = editor.Delete("Items", func(row fdb.Row) bool {
+		return row[0].Int32() == 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 deleted row but got %d", n)
+	}
+
+	if err := editor.Insert("Items", fdb.Row{entry(fdb.VariantI32, int32(5)), entry(fdb.VariantI64, int64(5))}); !errors.Is(err, fdb.ErrVariantMismatch) {
+		t.Errorf("expected %v but got %v", fdb.ErrVariantMismatch, err)
+	}
+
+	if err := editor.Insert("Items", fdb.Row{entry(fdb.VariantI32, int32(5)), entry(fdb.VariantNull, nil)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.AddColumn("Items", &fdb.Column{fdb.VariantBool, "isNew"}, entry(fdb.VariantI32, int32(0))); !errors.Is(err, fdb.ErrVariantMismatch) {
+		t.Errorf("expected %v but got %v", fdb.ErrVariantMismatch, err)
+	}
+
+	if err := editor.AddColumn("Items", &fdb.Column{fdb.VariantBool, "isRare"}, entry(fdb.VariantBool, false)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.DropTable("Dropped"); err != nil {
+		t.Fatal(err)
+	}
+
+	added := &fdb.Table{Name: "Added", Columns: []*fdb.Column{{fdb.VariantNVarChar, "key"}, {fdb.VariantI64, "value"}}}
+	if err := editor.AddTable(added); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.Insert("Added", fdb.Row{entry(fdb.VariantNVarChar, "answer"), entry(fdb.VariantI64, int64(42))}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputName := filepath.Join(dir, "output.fdb")
+	file, err := os.Create(outputName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.Flush(file); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	output, err := fdb.OpenReader(outputName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+
+	if _, ok := output.FindTable("Dropped"); ok {
+		t.Error("expected table Dropped to be removed")
+	}
+
+	items, ok := output.FindTable("Items")
+	if !ok {
+		t.Fatal("could not find table Items")
+	}
+
+	checkTable(t, &fdb.Table{Name: "Items", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantBool, "isRare"}}}, items)
+	checkRowStrings(t, "Items", []fdb.Row{
+		{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantNVarChar, "Brick"), entry(fdb.VariantBool, false)},
+		{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantNVarChar, "Round Plate"), entry(fdb.VariantBool, false)},
+		{entry(fdb.VariantI32, int32(4)), entry(fdb.VariantNVarChar, "Slope"), entry(fdb.VariantBool, false)},
+		{entry(fdb.VariantI32, int32(5)), entry(fdb.VariantNull, nil), entry(fdb.VariantBool, false)},
+	}, items.Rows())
+
+	row, err := items.HashTable().Find(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if name, _ := row[1].String(); name != "Slope" {
+		t.Errorf("expected row 4 to have name Slope but got %s", name)
+	}
+
+	addedTable, ok := output.FindTable("Added")
+	if !ok {
+		t.Fatal("could not find table Added")
+	}
+	checkTable(t, added, addedTable)
+
+	if _, err := addedTable.HashTable().FindString("answer"); err != nil {
+		t.Error(err)
+	}
+
+	// Unmodified tables keep their original row order.
+	original, _ := reader.FindTable("Untouched")
+	copied, ok := output.FindTable("Untouched")
+	if !ok {
+		t.Fatal("could not find table Untouched")
+	}
+
+	expectedStrings := rowStrings(t, original.Rows())
+	actualStrings := rowStrings(t, copied.Rows())
+	if !slices.Equal(expectedStrings, actualStrings) {
+		t.Errorf("Untouched:\nexpected = %v\nactual   = %v", expectedStrings, actualStrings)
+	}
+
+	// Unmodified tables are copied byte for byte, with only
+	// their pointers moved to the table's new offset.
+	originalBase, originalBytes := hashTableBytes(t, inputName, "Untouched")
+	copiedBase, copiedBytes := hashTableBytes(t, outputName, "Untouched")
+	if len(originalBytes) != len(copiedBytes) {
+		t.Fatalf("Untouched: expected %d bytes but got %d", len(originalBytes), len(copiedBytes))
+	}
+
+	for i := 0; i < len(originalBytes); i += 4 {
+		expected := binary.LittleEndian.Uint32(originalBytes[i:])
+		actual := binary.LittleEndian.Uint32(copiedBytes[i:])
+		if actual != expected && actual != expected-originalBase+copiedBase {
+			t.Fatalf("Untouched: offset %d: expected %#x but got %#x", i, expected, actual)
+		}
+	}
+}
+
+func TestEditSharedRows(t *testing.T) {
+	editor := fdb.Edit(&fdb.Reader{})
+
+	table := &fdb.Table{Name: "Shared", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantI32, "value"}}}
+	if err := editor.AddTable(table); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both rows share one backing array, and the first row
+	// has spare capacity which overlaps the second.
+	entries := []fdb.Entry{
+		entry(fdb.VariantI32, int32(1)), entry(fdb.VariantI32, int32(10)),
+		entry(fdb.VariantI32, int32(2)), entry(fdb.VariantI32, int32(20)),
+	}
+	first, second := fdb.Row(entries[:2]), fdb.Row(entries[2:])
+
+	if err := editor.Insert("Shared", first, second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.AddColumn("Shared", &fdb.Column{fdb.VariantBool, "flag"}, entry(fdb.VariantBool, true)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := editor.DropColumn("Shared", "id"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := entries[2].Int32(); v != 2 {
+		t.Errorf("expected the second row's key to be unchanged but got %d", v)
+	}
+
+	if v := entries[1].Int32(); v != 10 {
+		t.Errorf("expected the first row's value to be unchanged but got %d", v)
+	}
+
+	checkRowStrings(t, "Shared", []fdb.Row{
+		{entry(fdb.VariantI32, int32(10)), entry(fdb.VariantBool, true)},
+		{entry(fdb.VariantI32, int32(20)), entry(fdb.VariantBool, true)},
+	}, editor.Rows("Shared"))
+}
@@ -3,9 +3,13 @@ package fdb
 import (
 	"database/sql"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -175,8 +179,12 @@ func (e *DataEntry) scanInt64(i int64) error {
 	switch e.variant {
 	case VariantI32:
 		e.data = int32(i)
+	case VariantU32:
+		e.data = uint32(i)
 	case VariantI64:
 		e.data = i
+	case VariantU64:
+		e.data = uint64(i)
 	case VariantBool:
 		e.data = i != 0
 	default:
@@ -205,6 +213,37 @@ func (e *DataEntry) scanUint64(i uint64) error {
 	return nil
 }
 
+func (e *DataEntry) scanBytes(b []byte) error {
+	switch e.variant {
+	case VariantNull, VariantNVarChar, VariantText:
+		return e.scanString(string(b))
+	case VariantReal:
+		f, err := strconv.ParseFloat(string(b), 32)
+		if err != nil {
+			return fmt.Errorf("cannot scan []byte into %v: %v", e.variant, err)
+		}
+		return e.scanFloat64(f)
+	case VariantBool:
+		v, err := strconv.ParseBool(string(b))
+		if err != nil {
+			return fmt.Errorf("cannot scan []byte into %v: %v", e.variant, err)
+		}
+		return e.scanBool(v)
+	case VariantU32, VariantU64:
+		i, err := strconv.ParseUint(string(b), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan []byte into %v: %v", e.variant, err)
+		}
+		return e.scanUint64(i)
+	default:
+		i, err := strconv.ParseInt(string(b), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot scan []byte into %v: %v", e.variant, err)
+		}
+		return e.scanInt64(i)
+	}
+}
+
 func (e *DataEntry) scanTime(t time.Time) error {
 	if e.variant == VariantNull {
 		e.variant = VariantI64
@@ -233,6 +272,12 @@ func (e *DataEntry) scanTime(t time.Time) error {
 // int64 or uint64, [*DataEntry]'s value will be set to false if
 // value is 0 and true otherwise.
 //
+// If the variant is equal to [VariantU32] or [VariantU64] and value
+// is an int64, the value's bits are reinterpreted as unsigned.
+//
+// If the provided value is a []byte, as returned by some drivers
+// for text and numeric columns, it is parsed according to the variant.
+//
 // If the provided value is of type [time.Time] and the
 // variant is equal to [VariantI64], [*DataEntry]'s value will
 // be set to the number of seconds since epoch for that time.
@@ -246,8 +291,12 @@ func (e *DataEntry) Scan(value any) error {
 	switch v := value.(type) {
 	case string:
 		return e.scanString(v)
+	case []byte:
+		return e.scanBytes(v)
 	case float64:
 		return e.scanFloat64(v)
+	case float32:
+		return e.scanFloat64(float64(v))
 	case bool:
 		return e.scanBool(v)
 	case int64:
@@ -261,6 +310,133 @@ func (e *DataEntry) Scan(value any) error {
 	}
 }
 
+type jsonEntry struct {
+	Variant Variant         `json:"variant"`
+	Value   json.RawMessage `json:"value,omitempty"`
+}
+
+// Returns the float as a string if it cannot be encoded as a JSON number.
+func jsonFloat(v any) (string, bool) {
+	var f float64
+	switch v := v.(type) {
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return "", false
+	}
+
+	if math.IsNaN(f) {
+		if v, ok := v.(float32); ok && math.Float32bits(v) != canonicalNaN {
+			return fmt.Sprintf("NaN(%#08x)", math.Float32bits(v)), true
+		}
+		return "NaN", true
+	}
+
+	if math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// The bits of float32(math.NaN()).
+const canonicalNaN = 0x7fc00000
+
+// Parses a float encoded as a string by [jsonFloat].
+func parseJsonFloat(s string) (float32, error) {
+	if bits, ok := strings.CutPrefix(s, "NaN("); ok {
+		v, err := strconv.ParseUint(strings.TrimSuffix(bits, ")"), 0, 32)
+		if err != nil || !math.IsNaN(float64(math.Float32frombits(uint32(v)))) {
+			return 0, fmt.Errorf("invalid NaN: %s", s)
+		}
+		return math.Float32frombits(uint32(v)), nil
+	}
+
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, err
+	}
+	return float32(f), nil
+}
+
+// Encodes the entry as a JSON object containing
+// the entry's variant and value.
+//
+//	{"variant": "i32", "value": 10}
+//
+// NaN and infinite reals are encoded as the strings
+// "NaN", "+Inf", and "-Inf". NaNs with any other bits than
+// float32(math.NaN()) keep their bits, e.g. "NaN(0x7fc00001)".
+func (e DataEntry) MarshalJSON() ([]byte, error) {
+	entry := jsonEntry{Variant: e.variant}
+	if e.variant != VariantNull {
+		data := e.data
+		if s, ok := jsonFloat(data); ok {
+			data = s
+		}
+
+		value, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		entry.Value = value
+	}
+	return json.Marshal(entry)
+}
+
+func (e *DataEntry) UnmarshalJSON(data []byte) error {
+	entry := jsonEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return err
+	}
+
+	var value any
+	switch entry.Variant {
+	case VariantNull:
+	case VariantI32:
+		value = new(int32)
+	case VariantU32:
+		value = new(uint32)
+	case VariantReal:
+		value = new(float32)
+	case VariantNVarChar, VariantText:
+		value = new(string)
+	case VariantBool:
+		value = new(bool)
+	case VariantI64:
+		value = new(int64)
+	case VariantU64:
+		value = new(uint64)
+	default:
+		return fmt.Errorf("unknown variant: %v", entry.Variant)
+	}
+
+	e.variant = entry.Variant
+	e.data = nil
+
+	if value == nil {
+		return nil
+	}
+
+	var s string
+	if entry.Variant == VariantReal && json.Unmarshal(entry.Value, &s) == nil {
+		f, err := parseJsonFloat(s)
+		if err != nil {
+			return fmt.Errorf("%v: %v", entry.Variant, err)
+		}
+		e.data = f
+		return nil
+	}
+
+	if err := json.Unmarshal(entry.Value, value); err != nil {
+		return fmt.Errorf("%v: %v", entry.Variant, err)
+	}
+	e.data = reflect.ValueOf(value).Elem().Interface()
+
+	return nil
+}
+
 // Creates a new [*DataEntry] with the provided
 // [Variant] and optional data.
 //
@@ -276,3 +452,15 @@ func NewEntry(variant Variant, data ...any) *DataEntry {
 
 	return entry
 }
+
+// Returns a [*DataEntry] containing the value of the
+// provided [Entry]. The returned entry does not depend
+// on any underlying reader.
+func copyEntry(e Entry) (*DataEntry, error) {
+	value, err := Row{e}.Value(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEntry(e.Variant(), value), nil
+}
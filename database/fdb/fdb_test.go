@@ -1,11 +1,14 @@
 package fdb_test
 
 import (
+	"bytes"
 	"fmt"
 	"iter"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -268,3 +271,565 @@ func TestWrite(t *testing.T) {
 		{Name: "Table3", Columns: []*fdb.Column{{fdb.VariantBool, "boolId"}, {fdb.VariantI32, "index"}}},
 	}))
 }
+
+func syntheticRows(n int) iter.Seq2[fdb.Row, error] {
+	return func(yield func(fdb.Row, error) bool) {
+		for i := range n {
+			row := fdb.Row{
+				entry(fdb.VariantI32, int32(i/2)),
+				entry(fdb.VariantNVarChar, fmt.Sprintf("row%d", i)),
+				entry(fdb.VariantI64, int64(i)*-3),
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestWriteStreaming(t *testing.T) {
+	const numRows = 50000
+
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tables := []*fdb.Table{
+		{Name: "Large", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "value"}}},
+		{Name: "Small", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "value"}}},
+	}
+
+	fdbName := filepath.Join(dir, "streaming.fdb")
+	file, err := os.Create(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iterations := map[string]int{}
+
+	builder := fdb.NewBuilder(file, tables, fdb.BuilderOptions{MaxBufferedRows: 1000})
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		iterations[tableName]++
+		if tableName == "Small" {
+			return syntheticRows(100)
+		}
+		return syntheticRows(numRows)
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if iterations["Large"] != 2 {
+		t.Errorf("Large: expected rows to be iterated 2 times but got %d", iterations["Large"])
+	}
+
+	if iterations["Small"] != 1 {
+		t.Errorf("Small: expected rows to be iterated 1 time but got %d", iterations["Small"])
+	}
+
+	reader, err := fdb.OpenReader(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	stats, err := fdb.Stats(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every 2 rows share a key, so the streamed table has
+	// numRows/2 unique keys, just like a buffered table.
+	if stats[0].Name != "Large" || stats[0].Buckets != 32768 {
+		t.Errorf("Large: expected 32768 buckets but got %+v", stats[0])
+	}
+
+	for _, table := range tables {
+		expected := numRows
+		if table.Name == "Small" {
+			expected = 100
+		}
+
+		actual, ok := reader.FindTable(table.Name)
+		if !ok {
+			t.Fatalf("could not find table %s", table.Name)
+		}
+
+		seen := make([]bool, expected)
+		for row, err := range actual.Rows() {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := row[2].Int64()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			i := int(v / -3)
+			if i < 0 || i >= expected || seen[i] {
+				t.Fatalf("%s: unexpected row %d", table.Name, i)
+			}
+			seen[i] = true
+
+			name, err := row[1].String()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if name != fmt.Sprintf("row%d", i) || row[0].Int32() != int32(i/2) {
+				t.Errorf("%s: row %d has unexpected values: %d, %s", table.Name, i, row[0].Int32(), name)
+			}
+		}
+
+		for i, ok := range seen {
+			if !ok {
+				t.Errorf("%s: missing row %d", table.Name, i)
+			}
+		}
+
+		for _, id := range []int{0, expected / 4, expected/2 - 1} {
+			row, err := actual.HashTable().Find(id)
+			if err != nil {
+				t.Errorf("%s: find %d: %v", table.Name, id, err)
+				continue
+			}
+
+			if row[0].Int32() != int32(id) {
+				t.Errorf("%s: find %d: got row with id %d", table.Name, id, row[0].Int32())
+			}
+		}
+	}
+}
+
+// Returns the live heap size after a garbage collection.
+func liveHeap() uint64 {
+	runtime.GC()
+
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func TestStreamingMemory(t *testing.T) {
+	const (
+		numRows  = 100000
+		maxBytes = 4 << 20
+	)
+
+	if testing.Short() {
+		t.Skip("skipping large table in short mode")
+	}
+
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file, err := os.Create(filepath.Join(dir, "large.fdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tables := []*fdb.Table{
+		{Name: "Large", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "value"}}},
+	}
+
+	// Every key, string and int64 is unique, so holding any of
+	// them for the whole table would use several times maxBytes.
+	baseline := liveHeap()
+	peak := uint64(0)
+
+	builder := fdb.NewBuilder(file, tables, fdb.BuilderOptions{MaxBufferedRows: 1000, TempDir: dir})
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return func(yield func(fdb.Row, error) bool) {
+			for i := range numRows {
+				row := fdb.Row{
+					entry(fdb.VariantI32, int32(i)),
+					entry(fdb.VariantNVarChar, fmt.Sprintf("row%d", i)),
+					entry(fdb.VariantI64, int64(i)*-3),
+				}
+				if !yield(row, nil) {
+					return
+				}
+
+				if i%(numRows/4) == numRows/4-1 {
+					peak = max(peak, liveHeap())
+				}
+			}
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	peak = max(peak, liveHeap())
+
+	if used := peak - min(peak, baseline); used > maxBytes {
+		t.Errorf("expected at most %d bytes of live heap but got %d", maxBytes, used)
+	}
+
+	stats := builder.Stats()
+	if stats.UniqueStrings != numRows+4 || stats.UniqueInt64s != numRows {
+		t.Errorf("expected every value to be written but got %+v", stats)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		variant  fdb.Variant
+		value    any
+		expected any
+	}{
+		{fdb.VariantI32, []byte("-42"), int32(-42)},
+		{fdb.VariantU32, []byte("4294967295"), uint32(4294967295)},
+		{fdb.VariantReal, []byte("1.5"), float32(1.5)},
+		{fdb.VariantReal, float32(2.5), float32(2.5)},
+		{fdb.VariantNVarChar, []byte("name"), "name"},
+		{fdb.VariantBool, []byte("1"), true},
+		{fdb.VariantI64, []byte("-9223372036854775808"), int64(-9223372036854775808)},
+		{fdb.VariantU64, []byte("18446744073709551615"), uint64(18446744073709551615)},
+		{fdb.VariantText, []byte("<xml/>"), "<xml/>"},
+		{fdb.VariantNull, []byte("text"), "text"},
+	}
+
+	for _, test := range tests {
+		entry := fdb.NewEntry(test.variant)
+		if err := entry.Scan(test.value); err != nil {
+			t.Errorf("%v: scan %v: %v", test.variant, test.value, err)
+			continue
+		}
+
+		actual, err := fdb.Row{entry}.Value(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != test.expected {
+			t.Errorf("%v: scan %v: expected %v (%T) but got %v (%T)", test.variant, test.value, test.expected, test.expected, actual, actual)
+		}
+	}
+
+	if err := fdb.NewEntry(fdb.VariantI32).Scan([]byte("abc")); err == nil {
+		t.Error("expected error when scanning non-numeric []byte into i32")
+	}
+}
+
+// Yields rows whose keys are scattered such that
+// many keys share the same bucket.
+func scatteredRows(n int) iter.Seq2[fdb.Row, error] {
+	return func(yield func(fdb.Row, error) bool) {
+		for i := range n {
+			row := fdb.Row{
+				entry(fdb.VariantI32, int32((i*7919)%100003)),
+				entry(fdb.VariantNVarChar, fmt.Sprintf("row%d", i%100)),
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+func buildFile(name string, options fdb.BuilderOptions) ([]byte, error) {
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := fdb.NewBuilder(file, []*fdb.Table{
+		{Name: "Scattered", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}}},
+		{Name: "Duplicates", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "value"}}},
+	}, options)
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		if tableName == "Duplicates" {
+			return syntheticRows(500)
+		}
+		return scatteredRows(2000)
+	}); err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.Close()
+
+	return os.ReadFile(name)
+}
+
+func TestDeterministic(t *testing.T) {
+	const numBuilds = 5
+
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]fdb.BuilderOptions{
+		"default":   {},
+		"sorted":    {SortBuckets: true},
+		"streaming": {MaxBufferedRows: 100},
+	}
+
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			fdbName := filepath.Join(dir, name+".fdb")
+
+			expected, err := buildFile(fdbName, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := range numBuilds {
+				actual, err := buildFile(fdbName, options)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(expected, actual) {
+					t.Fatalf("build %d: output differs from the first build", i+1)
+				}
+			}
+		})
+	}
+
+	t.Run("sorted buckets", func(t *testing.T) {
+		// 2000 unique keys are stored within 2048 buckets.
+		const numBuckets = 2048
+
+		fdbName := filepath.Join(dir, "sorted.fdb")
+		if _, err := buildFile(fdbName, fdb.BuilderOptions{SortBuckets: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := fdb.OpenReader(fdbName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		table, _ := reader.FindTable("Scattered")
+
+		previous := -1
+		for row, err := range table.Rows() {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := row.Id()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if previous >= 0 && previous%numBuckets == id%numBuckets && previous > id {
+				t.Fatalf("bucket %d: key %d comes after key %d", id%numBuckets, id, previous)
+			}
+			previous = id
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		fdbName := filepath.Join(dir, "source.fdb")
+		if _, err := buildFile(fdbName, fdb.BuilderOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := fdb.OpenReader(fdbName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		flush := func(name string) []byte {
+			editor := fdb.Edit(reader)
+			if err := editor.Insert("Scattered", fdb.Row{entry(fdb.VariantI32, int32(7)), entry(fdb.VariantNVarChar, "inserted")}); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Create(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			if err := editor.Flush(file); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(file.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return data
+		}
+
+		expected := flush("edit1.fdb")
+		for i := range numBuilds {
+			if actual := flush(fmt.Sprintf("edit%d.fdb", i+2)); !bytes.Equal(expected, actual) {
+				t.Fatalf("flush %d: output differs from the first flush", i+1)
+			}
+		}
+	})
+}
+
+func TestInterning(t *testing.T) {
+	const numRows = 100
+
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fdbName := filepath.Join(dir, "interned.fdb")
+	file, err := os.Create(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := fdb.NewBuilder(file, []*fdb.Table{
+		{Name: "Interned", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "signed"}, {fdb.VariantU64, "unsigned"}}},
+	})
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return func(yield func(fdb.Row, error) bool) {
+			for i := range numRows {
+				if !yield(fdb.Row{
+					entry(fdb.VariantI32, int32(i)),
+					entry(fdb.VariantNVarChar, "same"),
+					entry(fdb.VariantI64, int64(-1)),
+					entry(fdb.VariantU64, uint64(18446744073709551615)),
+				}, nil) {
+					return
+				}
+			}
+		}
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+
+	info, err := file.Stat()
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := builder.Stats()
+
+	// The table name, 4 column names, and 1 string per row.
+	if stats.Strings != 5+numRows || stats.UniqueStrings != 6 {
+		t.Errorf("expected %d strings (6 unique) but got %d (%d unique)", 5+numRows, stats.Strings, stats.UniqueStrings)
+	}
+
+	// -1 and the max uint64 share the same bits.
+	if stats.Int64s != 2*numRows || stats.UniqueInt64s != 1 {
+		t.Errorf("expected %d int64s (1 unique) but got %d (%d unique)", 2*numRows, stats.Int64s, stats.UniqueInt64s)
+	}
+
+	// "same" is 8 bytes including its null terminator and padding.
+	if expected := int64((numRows-1)*8 + (2*numRows-1)*8); stats.BytesSaved != expected {
+		t.Errorf("expected %d bytes saved but got %d", expected, stats.BytesSaved)
+	}
+
+	if stats.Size != info.Size() {
+		t.Errorf("expected size %d but got %d", info.Size(), stats.Size)
+	}
+
+	reader, err := fdb.OpenReader(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	table, _ := reader.FindTable("Interned")
+	for row, err := range table.Rows() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name, _ := row[1].String()
+		signed, _ := row[2].Int64()
+		unsigned, _ := row[3].Uint64()
+		if name != "same" || signed != -1 || unsigned != 18446744073709551615 {
+			t.Errorf("row %d: unexpected values: %q, %d, %d", row[0].Int32(), name, signed, unsigned)
+		}
+	}
+}
+
+func TestNumBuckets(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	numBuckets := func(table *fdb.Table, numKeys int) int {
+		if table.Name == "Scattered" {
+			return 7
+		}
+		return 0
+	}
+
+	chains := [7]int{}
+	for i := range 2000 {
+		chains[((i*7919)%100003)%7]++
+	}
+
+	tests := map[string]fdb.BuilderOptions{
+		"buffered":  {NumBuckets: numBuckets},
+		"streaming": {NumBuckets: numBuckets, MaxBufferedRows: 100},
+	}
+
+	for name, options := range tests {
+		t.Run(name, func(t *testing.T) {
+			fdbName := filepath.Join(dir, name+".fdb")
+			if _, err := buildFile(fdbName, options); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := fdb.OpenReader(fdbName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			stats, err := fdb.Stats(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []fdb.TableStats{
+				{Name: "Duplicates", Rows: 500, Buckets: 256, NullBuckets: 6, LongestChain: 2},
+				{Name: "Scattered", Rows: 2000, Buckets: 7, NullBuckets: 0, LongestChain: slices.Max(chains[:])},
+			}
+			if len(stats) != len(expected) {
+				t.Fatalf("expected %d tables but got %d", len(expected), len(stats))
+			}
+
+			for i, s := range stats {
+				if s != expected[i] {
+					t.Errorf("expected %+v but got %+v", expected[i], s)
+				}
+			}
+
+			table, _ := reader.FindTable("Scattered")
+			for i := range 2000 {
+				id := (i * 7919) % 100003
+				if _, err := table.HashTable().Find(id); err != nil {
+					t.Errorf("find %d: %v", id, err)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := buildFile(filepath.Join(dir, "invalid.fdb"), fdb.BuilderOptions{
+			NumBuckets: func(table *fdb.Table, numKeys int) int { return -1 },
+		})
+		if err == nil {
+			t.Error("expected error for a negative number of buckets")
+		}
+	})
+}
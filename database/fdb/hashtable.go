@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"math"
 )
 
 var (
@@ -256,8 +258,9 @@ func (r Rows) Err() error {
 //	+-------+
 //
 // Each row is identified by the first column's value
-// converted to an integer. The bucket linked list that
-// contains the row is then located at the index: ID % the # of buckets.
+// hashed to an unsigned 32-bit key (see [Row.Key]). The bucket
+// linked list that contains the row is then located at the
+// index: key % the # of buckets.
 type HashTable struct {
 	r          io.ReadSeeker
 	base       int64
@@ -296,41 +299,172 @@ func (h HashTable) Bucket(i int) (*Bucket, error) {
 	return b, nil
 }
 
-// Returns the first row corresponding to the provided id.
-// If no row exists, Find returns a wrapped [ErrRowNotFound] error.
-func (h HashTable) Find(id int) (Row, error) {
-	bucket, err := h.Bucket(id % h.numBuckets)
-	if errors.Is(err, ErrNullData) {
-		return nil, fmt.Errorf("hash table: %w", ErrRowNotFound)
+// Yields every row within the bucket of the key for which
+// match returns true.
+func (h HashTable) findAll(key uint32, match func(entry Entry) (bool, error)) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		if h.numBuckets == 0 {
+			return
+		}
+
+		bucket, err := h.Bucket(int(key % uint32(h.numBuckets)))
+		if errors.Is(err, ErrNullData) {
+			return
+		}
+
+		if err != nil {
+			yield(nil, fmt.Errorf("hash table: %v", err))
+			return
+		}
+
+		for bucket.Next() {
+			row := bucket.Row()
+			if len(row) == 0 || row[0].Variant() == VariantNull {
+				continue
+			}
+
+			ok, err := match(row[0])
+			if err != nil {
+				yield(nil, fmt.Errorf("hash table: %v", err))
+				return
+			}
+
+			if ok && !yield(row, nil) {
+				return
+			}
+		}
+
+		if err := bucket.Err(); err != nil {
+			yield(nil, fmt.Errorf("hash table: %v", err))
+		}
 	}
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("hash table: %v", err)
+// Returns the first row within the bucket of the key for which
+// match returns true.
+func (h HashTable) find(key uint32, match func(entry Entry) (bool, error)) (Row, error) {
+	for row, err := range h.findAll(key, match) {
+		return row, err
 	}
+	return nil, fmt.Errorf("hash table: %w", ErrRowNotFound)
+}
 
-	for bucket.Next() {
-		row := bucket.Row()
+func matchId(id int) func(entry Entry) (bool, error) {
+	return func(entry Entry) (bool, error) {
+		rowId, err := (&Row{entry}).Id()
+		return rowId == id, err
+	}
+}
 
-		rowId, err := row.Id()
-		if errors.Is(err, ErrNullData) {
-			continue
+func matchInt64(id int64) func(entry Entry) (bool, error) {
+	return func(entry Entry) (bool, error) {
+		switch entry.Variant() {
+		case VariantI32:
+			return int64(entry.Int32()) == id, nil
+		case VariantU32:
+			return int64(entry.Uint32()) == id, nil
+		case VariantI64:
+			v, err := entry.Int64()
+			return v == id, err
+		case VariantU64:
+			v, err := entry.Uint64()
+			return id >= 0 && v == uint64(id), err
 		}
+		return false, nil
+	}
+}
 
-		if err != nil {
-			return nil, fmt.Errorf("hash table: %v", err)
+func matchUint64(id uint64) func(entry Entry) (bool, error) {
+	return func(entry Entry) (bool, error) {
+		switch entry.Variant() {
+		case VariantI32:
+			v := entry.Int32()
+			return v >= 0 && uint64(v) == id, nil
+		case VariantU32:
+			return uint64(entry.Uint32()) == id, nil
+		case VariantI64:
+			v, err := entry.Int64()
+			return v >= 0 && uint64(v) == id, err
+		case VariantU64:
+			v, err := entry.Uint64()
+			return v == id, err
 		}
+		return false, nil
+	}
+}
+
+func matchFloat(bits uint32) func(entry Entry) (bool, error) {
+	return func(entry Entry) (bool, error) {
+		return entry.Variant() == VariantReal && math.Float32bits(entry.Float32()) == bits, nil
+	}
+}
 
-		if rowId == id {
-			return bucket.Row(), nil
+func matchString(id string) func(entry Entry) (bool, error) {
+	return func(entry Entry) (bool, error) {
+		if entry.Variant() != VariantNVarChar && entry.Variant() != VariantText {
+			return false, nil
 		}
+
+		s, err := entry.String()
+		return s == id, err
 	}
+}
 
-	return nil, fmt.Errorf("hash table: %w", ErrRowNotFound)
+// Returns the first row whose [Row.Id] is equal to the provided id.
+// If no row exists, Find returns a wrapped [ErrRowNotFound] error.
+func (h HashTable) Find(id int) (Row, error) {
+	return h.find(uint32(id), matchId(id))
 }
 
-// Returns the first row corresponding to the provided id.
+// Returns the first row whose key is an integer equal to the
+// provided id, regardless of the key's integer variant.
+func (h HashTable) FindInt64(id int64) (Row, error) {
+	return h.find(uint32(id), matchInt64(id))
+}
+
+// Returns the first row whose key is an integer equal to the
+// provided id, regardless of the key's integer variant.
+func (h HashTable) FindUint64(id uint64) (Row, error) {
+	return h.find(uint32(id), matchUint64(id))
+}
+
+// Returns the first row whose key is a [VariantReal] with the
+// same bits as the provided id.
+func (h HashTable) FindFloat(id float32) (Row, error) {
+	return h.find(math.Float32bits(id), matchFloat(math.Float32bits(id)))
+}
+
+// Returns the first row whose key is a string equal
+// to the provided id.
 func (h HashTable) FindString(id string) (Row, error) {
-	return h.Find(int(Sfhash([]byte(id))))
+	return h.find(Sfhash([]byte(id)), matchString(id))
+}
+
+// Yields every row whose [Row.Id] is equal to the provided id,
+// in the order they are stored. Tables may contain several rows
+// with the same key, e.g. one row per component of an object.
+func (h HashTable) FindAll(id int) iter.Seq2[Row, error] {
+	return h.findAll(uint32(id), matchId(id))
+}
+
+// Yields every row matched by [HashTable.FindInt64].
+func (h HashTable) FindAllInt64(id int64) iter.Seq2[Row, error] {
+	return h.findAll(uint32(id), matchInt64(id))
+}
+
+// Yields every row matched by [HashTable.FindUint64].
+func (h HashTable) FindAllUint64(id uint64) iter.Seq2[Row, error] {
+	return h.findAll(uint32(id), matchUint64(id))
+}
+
+// Yields every row matched by [HashTable.FindFloat].
+func (h HashTable) FindAllFloat(id float32) iter.Seq2[Row, error] {
+	return h.findAll(math.Float32bits(id), matchFloat(math.Float32bits(id)))
+}
+
+// Yields every row matched by [HashTable.FindString].
+func (h HashTable) FindAllString(id string) iter.Seq2[Row, error] {
+	return h.findAll(Sfhash([]byte(id)), matchString(id))
 }
 
 func (h HashTable) Rows() (*Rows, error) {
@@ -346,3 +480,29 @@ func (h HashTable) Rows() (*Rows, error) {
 
 	return r, nil
 }
+
+// Returns every bucket within the hash table, preserving
+// the order of the rows within each bucket.
+func (h HashTable) buckets() ([][]Row, error) {
+	buckets := make([][]Row, h.numBuckets)
+	for i := range buckets {
+		bucket, err := h.Bucket(i)
+		if errors.Is(err, ErrNullData) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("hash table: %v", err)
+		}
+
+		for bucket.Next() {
+			buckets[i] = append(buckets[i], bucket.Row())
+		}
+
+		if err := bucket.Err(); err != nil {
+			return nil, fmt.Errorf("hash table: %v", err)
+		}
+	}
+
+	return buckets, nil
+}
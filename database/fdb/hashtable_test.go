@@ -0,0 +1,391 @@
+package fdb_test
+
+import (
+	"errors"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+type keyTest struct {
+	key    *fdb.DataEntry
+	name   string
+	bucket int
+	find   func(h *fdb.HashTable) (fdb.Row, error)
+}
+
+// 7 buckets, since keys which only differ above 32 bits
+// or by their sign share the same bucket in power of 2 tables.
+const keyTestBuckets = 7
+
+var keyTests = map[string][]keyTest{
+	"I32": {
+		{entry(fdb.VariantI32, int32(-1)), "minus one", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.Find(-1) }},
+		{entry(fdb.VariantI32, int32(math.MinInt32)), "min", 2, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindInt64(math.MinInt32) }},
+		{entry(fdb.VariantI32, int32(5)), "five", 5, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindUint64(5) }},
+	},
+	"U32": {
+		{entry(fdb.VariantU32, uint32(math.MaxUint32)), "max", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindUint64(math.MaxUint32) }},
+		{entry(fdb.VariantU32, uint32(10)), "ten", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindInt64(10) }},
+	},
+	"I64": {
+		{entry(fdb.VariantI64, int64(-1)), "minus one", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindInt64(-1) }},
+		{entry(fdb.VariantI64, int64(1<<32+3)), "high bits", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindInt64(1<<32 + 3) }},
+		{entry(fdb.VariantI64, int64(math.MinInt64)), "min", 0, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindInt64(math.MinInt64) }},
+	},
+	"U64": {
+		{entry(fdb.VariantU64, uint64(math.MaxUint64)), "max", 3, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindUint64(math.MaxUint64) }},
+		{entry(fdb.VariantU64, uint64(1<<40+6)), "high bits", 6, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindUint64(1<<40 + 6) }},
+	},
+	"Real": {
+		{entry(fdb.VariantReal, float32(1.5)), "one and a half", 6, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindFloat(1.5) }},
+		{entry(fdb.VariantReal, float32(math.Copysign(0, -1))), "negative zero", 2, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindFloat(float32(math.Copysign(0, -1))) }},
+		{entry(fdb.VariantReal, float32(0)), "zero", 0, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindFloat(0) }},
+	},
+	"Bool": {
+		{entry(fdb.VariantBool, true), "true", 1, func(h *fdb.HashTable) (fdb.Row, error) { return h.Find(1) }},
+		{entry(fdb.VariantBool, false), "false", 0, func(h *fdb.HashTable) (fdb.Row, error) { return h.Find(0) }},
+	},
+	"NVarChar": {
+		{entry(fdb.VariantNVarChar, "Objects"), "objects", int(fdb.Sfhash([]byte("Objects")) % keyTestBuckets), func(h *fdb.HashTable) (fdb.Row, error) { return h.FindString("Objects") }},
+		{entry(fdb.VariantNVarChar, ""), "empty", 0, func(h *fdb.HashTable) (fdb.Row, error) { return h.FindString("") }},
+	},
+}
+
+func bucketNames(t *testing.T, h *fdb.HashTable, i int) []string {
+	bucket, err := h.Bucket(i)
+	if errors.Is(err, fdb.ErrNullData) {
+		return nil
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for bucket.Next() {
+		name, _ := bucket.Row()[1].String()
+		names = append(names, name)
+	}
+
+	if err := bucket.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return names
+}
+
+func TestKeys(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tables := []*fdb.Table{}
+	for name, tests := range keyTests {
+		tables = append(tables, &fdb.Table{
+			Name:    name,
+			Columns: []*fdb.Column{{tests[0].key.Variant(), "key"}, {fdb.VariantNVarChar, "name"}},
+		})
+	}
+
+	fdbName := filepath.Join(dir, "keys.fdb")
+	file, err := os.Create(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builder := fdb.NewBuilder(file, tables, fdb.BuilderOptions{
+		NumBuckets: func(table *fdb.Table, numKeys int) int { return keyTestBuckets },
+	})
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return func(yield func(fdb.Row, error) bool) {
+			for _, test := range keyTests[tableName] {
+				if !yield(fdb.Row{test.key, entry(fdb.VariantNVarChar, test.name)}, nil) {
+					return
+				}
+			}
+		}
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reader, err := fdb.OpenReader(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for tableName, tests := range keyTests {
+		t.Run(tableName, func(t *testing.T) {
+			table, ok := reader.FindTable(tableName)
+			if !ok {
+				t.Fatalf("missing table: %s", tableName)
+			}
+
+			for _, test := range tests {
+				names := bucketNames(t, table.HashTable(), test.bucket)
+				found := false
+				for _, name := range names {
+					found = found || name == test.name
+				}
+
+				if !found {
+					t.Errorf("%s: expected row in bucket %d but bucket contains %q", test.name, test.bucket, names)
+				}
+
+				row, err := test.find(table.HashTable())
+				if err != nil {
+					t.Errorf("%s: find: %v", test.name, err)
+					continue
+				}
+
+				if name, _ := row[1].String(); name != test.name {
+					t.Errorf("%s: find returned %q", test.name, name)
+				}
+			}
+		})
+	}
+
+	table, _ := reader.FindTable("I64")
+	if _, err := table.HashTable().FindInt64(3); !errors.Is(err, fdb.ErrRowNotFound) {
+		t.Errorf("expected keys with the same bucket to not match but got: %v", err)
+	}
+
+	table, _ = reader.FindTable("U32")
+	if _, err := table.HashTable().FindInt64(-1); !errors.Is(err, fdb.ErrRowNotFound) {
+		t.Errorf("expected negative key to not match unsigned key but got: %v", err)
+	}
+}
+
+// Keys of testdata/basic.fdb, a committed fixture, and the
+// buckets they are stored in.
+var fixtureKeyTests = []struct {
+	table  string
+	key    any
+	bucket int
+}{
+	{"Accounts", uint32(0), 0},
+	{"Accounts", uint32(3), 3},
+	{"Accounts", uint32(4), 4},
+	{"NPCs", "Doctor Overbuild", 1},
+	{"NPCs", "Hael Storm", 1},
+	{"NPCs", "Duke Exeter", 3},
+	{"NPCs", "Vanda Darkflame", 3},
+}
+
+// Checks that every row is stored in the bucket of its key.
+func checkKeyBuckets(t *testing.T, table *fdb.Table) {
+	stats, err := table.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range stats.Buckets {
+		bucket, err := table.HashTable().Bucket(i)
+		if errors.Is(err, fdb.ErrNullData) {
+			continue
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for bucket.Next() {
+			key, err := bucket.Row().Key()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if int(key%uint32(stats.Buckets)) != i {
+				v, _ := bucket.Row().Value(0)
+				t.Errorf("%s: %v: expected bucket %d but found in bucket %d", table.Name, v, key%uint32(stats.Buckets), i)
+			}
+		}
+
+		if err := bucket.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Checks that the key of every row is found by the typed
+// lookup helper for its variant.
+func checkKeyLookups(t *testing.T, table *fdb.Table) {
+	h := table.HashTable()
+	if h == nil {
+		return
+	}
+
+	for row, err := range table.Rows() {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := row.Value(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var found fdb.Row
+		switch key := key.(type) {
+		case int32:
+			found, err = h.FindInt64(int64(key))
+		case uint32:
+			found, err = h.FindUint64(uint64(key))
+		case int64:
+			found, err = h.FindInt64(key)
+		case uint64:
+			found, err = h.FindUint64(key)
+		case float32:
+			found, err = h.FindFloat(key)
+		case string:
+			found, err = h.FindString(key)
+		default:
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v: %v", table.Name, key, err)
+			continue
+		}
+
+		if v, _ := found.Value(0); v != key && !isNaN(key) {
+			t.Errorf("%s: %v: found row with key %v", table.Name, key, v)
+		}
+	}
+}
+
+func isNaN(v any) bool {
+	f, ok := v.(float32)
+	return ok && f != f
+}
+
+func TestFixtureKeys(t *testing.T) {
+	reader, err := fdb.OpenReader(filepath.Join("testdata", "basic.fdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for _, table := range reader.Tables() {
+		checkKeyBuckets(t, table)
+		checkKeyLookups(t, table)
+	}
+
+	for _, test := range fixtureKeyTests {
+		table, ok := reader.FindTable(test.table)
+		if !ok {
+			t.Fatalf("missing table: %s", test.table)
+		}
+
+		bucket, err := table.HashTable().Bucket(test.bucket)
+		if err != nil {
+			t.Fatalf("%s: %v: %v", test.table, test.key, err)
+		}
+
+		keys := []any{}
+		for bucket.Next() {
+			v, _ := bucket.Row().Value(0)
+			keys = append(keys, v)
+		}
+
+		if !slices.Contains(keys, test.key) {
+			t.Errorf("%s: %v: expected row in bucket %d but bucket contains %v", test.table, test.key, test.bucket, keys)
+		}
+	}
+}
+
+// Checks the buckets and lookups of every row of a client's
+// cdclient.fdb, if GOVERBUILD_CDCLIENT is set to its path. No
+// client file is committed, so the buckets of i64, u64, negative
+// i32 and real keys are only checked against the client's own
+// files when this test runs. See [keyTests] for the buckets those
+// keys are expected to have.
+func TestRetailKeys(t *testing.T) {
+	name := os.Getenv("GOVERBUILD_CDCLIENT")
+	if len(name) == 0 {
+		t.Skip("GOVERBUILD_CDCLIENT is not set")
+	}
+
+	reader, err := fdb.OpenReader(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for _, table := range reader.Tables() {
+		t.Run(table.Name, func(t *testing.T) {
+			checkKeyBuckets(t, table)
+			checkKeyLookups(t, table)
+		})
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fdbName := filepath.Join(dir, "repeated.fdb")
+	file, err := os.Create(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every 2 rows share a key.
+	builder := fdb.NewBuilder(file, []*fdb.Table{
+		{Name: "Repeated", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI64, "value"}}},
+	})
+	if err := builder.Flush(func(tableName string) iter.Seq2[fdb.Row, error] {
+		return syntheticRows(100)
+	}); err != nil {
+		file.Close()
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reader, err := fdb.OpenReader(fdbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	table, _ := reader.FindTable("Repeated")
+
+	tests := map[string]iter.Seq2[fdb.Row, error]{
+		"FindAll":       table.HashTable().FindAll(7),
+		"FindAllInt64":  table.HashTable().FindAllInt64(7),
+		"FindAllUint64": table.HashTable().FindAllUint64(7),
+	}
+
+	for name, rows := range tests {
+		names := []string{}
+		for row, err := range rows {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, _ := row[1].String()
+			names = append(names, s)
+		}
+
+		if !slices.Equal(names, []string{"row14", "row15"}) {
+			t.Errorf("%s: expected rows 14 and 15 but got %v", name, names)
+		}
+	}
+
+	for range table.HashTable().FindAllInt64(-7) {
+		t.Error("expected no rows for a missing key")
+	}
+}
@@ -16,13 +16,34 @@ type Writer struct {
 
 	order binary.ByteOrder
 
+	// Identical strings and 64-bit integers are written once,
+	// and every reference points at the same copy.
 	Strings  map[string]uint32
+	Int64s   map[uint64]uint32
+	Interned InternStats
+
+	// The maximum number of strings, and of 64-bit integers, kept
+	// for sharing. Values written after the limit is reached are
+	// not shared with later values. There is no limit when <= 0.
+	MaxInterned int
+
 	Deferred []struct {
 		Home  uint32
 		Value any
 	}
 }
 
+// Counts the deferred values flushed by a [Writer].
+type InternStats struct {
+	Strings       int
+	UniqueStrings int
+	Int64s        int
+	UniqueInt64s  int
+
+	// The number of bytes not written because of shared values.
+	BytesSaved int64
+}
+
 func New(ws io.WriteSeeker, order binary.ByteOrder, pos uint32) *Writer {
 	return &Writer{
 		ws:    ws,
@@ -31,6 +52,11 @@ func New(ws io.WriteSeeker, order binary.ByteOrder, pos uint32) *Writer {
 	}
 }
 
+// Returns the current position of the writer.
+func (w *Writer) Pos() uint32 {
+	return w.pos
+}
+
 func (w *Writer) deferValue(home uint32, v any) error {
 	if err := w.PutUint32(0); err != nil {
 		return err
@@ -105,6 +131,50 @@ func (w *Writer) PutBool(b bool) error {
 	return nil
 }
 
+func (w *Writer) PutBytes(b []byte) error {
+	if _, err := w.ws.Write(b); err != nil {
+		return err
+	}
+	w.pos += uint32(len(b))
+
+	return nil
+}
+
+// Writes length uint32's with every byte set to fill.
+func (w *Writer) PutFilled(length int, fill byte) error {
+	b := make([]byte, length*4)
+	if fill != 0 {
+		for i := range b {
+			b[i] = fill
+		}
+	}
+
+	if _, err := w.ws.Write(b); err != nil {
+		return err
+	}
+	w.pos += uint32(len(b))
+
+	return nil
+}
+
+// Overwrites the uint32 at pos with i and then
+// returns to the writer's current position.
+func (w *Writer) PatchUint32(pos, i uint32) error {
+	if _, err := w.ws.Seek(int64(pos), io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w.ws, w.order, i); err != nil {
+		return err
+	}
+
+	if _, err := w.ws.Seek(int64(w.pos), io.SeekStart); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (w *Writer) writeString(pos uint32, s string) (n int, err error) {
 	const alignment = 4
 
@@ -136,6 +206,8 @@ func (w *Writer) flushString(home, pos uint32, s string) (n int, err error) {
 		w.Strings = make(map[string]uint32)
 	}
 
+	w.Interned.Strings++
+
 	address, ok := w.Strings[s]
 	if !ok {
 		written, err := w.writeString(pos, s)
@@ -144,8 +216,14 @@ func (w *Writer) flushString(home, pos uint32, s string) (n int, err error) {
 		}
 		n += written
 
-		w.Strings[s] = pos
+		if w.MaxInterned <= 0 || len(w.Strings) < w.MaxInterned {
+			w.Strings[s] = pos
+		}
 		address = pos
+		w.Interned.UniqueStrings++
+	} else {
+		// Length of the string, its null terminator, and padding.
+		w.Interned.BytesSaved += int64((len(s) + 4) &^ 3)
 	}
 
 	if _, err := w.ws.Seek(int64(home), io.SeekStart); err != nil {
@@ -159,45 +237,59 @@ func (w *Writer) flushString(home, pos uint32, s string) (n int, err error) {
 	return n, nil
 }
 
-func (w *Writer) flushInt64(home, pos uint32, v int64) (n int, err error) {
-	if _, err := w.ws.Seek(int64(pos), io.SeekStart); err != nil {
-		return 0, fmt.Errorf("flush int64: %v", err)
+// Writes the 8 bytes of a signed or unsigned 64-bit integer, identified
+// by its bits, and points the value at home to it.
+func (w *Writer) flush64(home, pos uint32, bits uint64) (n int, err error) {
+	if w.Int64s == nil {
+		w.Int64s = make(map[uint64]uint32)
 	}
 
-	if err := binary.Write(w.ws, w.order, v); err != nil {
-		return 0, fmt.Errorf("flush int64: %v", err)
+	w.Interned.Int64s++
+
+	address, ok := w.Int64s[bits]
+	if !ok {
+		if _, err := w.ws.Seek(int64(pos), io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		if err := binary.Write(w.ws, w.order, bits); err != nil {
+			return 0, err
+		}
+		n += 8
+
+		if w.MaxInterned <= 0 || len(w.Int64s) < w.MaxInterned {
+			w.Int64s[bits] = pos
+		}
+		address = pos
+		w.Interned.UniqueInt64s++
+	} else {
+		w.Interned.BytesSaved += 8
 	}
-	n += 8
 
 	if _, err := w.ws.Seek(int64(home), io.SeekStart); err != nil {
-		return 0, fmt.Errorf("flush int64: %v", err)
+		return 0, err
 	}
 
-	if err := binary.Write(w.ws, w.order, pos); err != nil {
-		return 0, fmt.Errorf("flush int64: %v", err)
+	if err := binary.Write(w.ws, w.order, address); err != nil {
+		return 0, err
 	}
 
 	return n, nil
 }
 
-func (w *Writer) flushUint64(home, pos uint32, v uint64) (n int, err error) {
-	if _, err := w.ws.Seek(int64(pos), io.SeekStart); err != nil {
-		return 0, fmt.Errorf("flush uint64: %v", err)
-	}
-
-	if err := binary.Write(w.ws, w.order, v); err != nil {
-		return 0, fmt.Errorf("flush uint64: %v", err)
-	}
-	n += 8
-
-	if _, err := w.ws.Seek(int64(home), io.SeekStart); err != nil {
-		return 0, fmt.Errorf("flush uint64: %v", err)
+func (w *Writer) flushInt64(home, pos uint32, v int64) (n int, err error) {
+	n, err = w.flush64(home, pos, uint64(v))
+	if err != nil {
+		return 0, fmt.Errorf("flush int64: %v", err)
 	}
+	return n, nil
+}
 
-	if err := binary.Write(w.ws, w.order, pos); err != nil {
+func (w *Writer) flushUint64(home, pos uint32, v uint64) (n int, err error) {
+	n, err = w.flush64(home, pos, v)
+	if err != nil {
 		return 0, fmt.Errorf("flush uint64: %v", err)
 	}
-
 	return n, nil
 }
 
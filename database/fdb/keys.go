@@ -0,0 +1,186 @@
+package fdb
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// The size of the buffer used to read each run of spilled keys.
+const keyRunBufferSize = 256
+
+// Counts the unique keys of a table while holding at most
+// max keys in memory. Keys beyond max are sorted, deduplicated
+// and spilled to a temporary file in runs, which are merged
+// to count the keys once every key was added.
+type keyCounter struct {
+	max  int
+	dir  string
+	keys []uint32
+
+	file *os.File
+	runs []keyRun
+	size int64
+}
+
+type keyRun struct {
+	offset, length int64
+}
+
+func newKeyCounter(max int, dir string) *keyCounter {
+	return &keyCounter{
+		max:  max,
+		dir:  dir,
+		keys: make([]uint32, 0, max),
+	}
+}
+
+func (c *keyCounter) add(key uint32) error {
+	c.keys = append(c.keys, key)
+	if len(c.keys) < c.max {
+		return nil
+	}
+
+	c.keys = compactKeys(c.keys)
+
+	// Keeps compacting in memory while duplicates free enough space.
+	if len(c.keys) < c.max/2 {
+		return nil
+	}
+	return c.spill()
+}
+
+// Writes the sorted and deduplicated keys to the
+// temporary file as a new run.
+func (c *keyCounter) spill() error {
+	if c.file == nil {
+		file, err := os.CreateTemp(c.dir, "fdb-keys*")
+		if err != nil {
+			return fmt.Errorf("spill keys: %v", err)
+		}
+		c.file = file
+	}
+
+	w := bufio.NewWriter(c.file)
+	if err := binary.Write(w, order, c.keys); err != nil {
+		return fmt.Errorf("spill keys: %v", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("spill keys: %v", err)
+	}
+
+	length := int64(len(c.keys)) * 4
+	c.runs = append(c.runs, keyRun{c.size, length})
+	c.size += length
+
+	c.keys = c.keys[:0]
+	return nil
+}
+
+// Returns the number of unique keys added to the counter.
+func (c *keyCounter) count() (int, error) {
+	c.keys = compactKeys(c.keys)
+	if len(c.runs) == 0 {
+		return len(c.keys), nil
+	}
+
+	if len(c.keys) > 0 {
+		if err := c.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	runs := keyRunHeap{}
+	for _, run := range c.runs {
+		r := &keyRunReader{r: bufio.NewReaderSize(io.NewSectionReader(c.file, run.offset, run.length), keyRunBufferSize)}
+
+		ok, err := r.next()
+		if err != nil {
+			return 0, fmt.Errorf("count keys: %v", err)
+		}
+
+		if ok {
+			runs = append(runs, r)
+		}
+	}
+	heap.Init(&runs)
+
+	n := 0
+	last := uint32(0)
+	for len(runs) > 0 {
+		r := runs[0]
+		if n == 0 || r.key != last {
+			last = r.key
+			n++
+		}
+
+		ok, err := r.next()
+		if err != nil {
+			return 0, fmt.Errorf("count keys: %v", err)
+		}
+
+		if ok {
+			heap.Fix(&runs, 0)
+		} else {
+			heap.Pop(&runs)
+		}
+	}
+
+	return n, nil
+}
+
+// Removes the temporary file, if one was created.
+func (c *keyCounter) Close() error {
+	if c.file == nil {
+		return nil
+	}
+
+	c.file.Close()
+	return os.Remove(c.file.Name())
+}
+
+type keyRunReader struct {
+	r   *bufio.Reader
+	buf [4]byte
+	key uint32
+}
+
+func (r *keyRunReader) next() (bool, error) {
+	if _, err := io.ReadFull(r.r, r.buf[:]); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	r.key = order.Uint32(r.buf[:])
+	return true, nil
+}
+
+// A min-heap of runs ordered by their current key.
+type keyRunHeap []*keyRunReader
+
+func (h keyRunHeap) Len() int           { return len(h) }
+func (h keyRunHeap) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h keyRunHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *keyRunHeap) Push(x any) {
+	*h = append(*h, x.(*keyRunReader))
+}
+
+func (h *keyRunHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// Sorts the keys and removes duplicates in place.
+func compactKeys(keys []uint32) []uint32 {
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
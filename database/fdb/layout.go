@@ -0,0 +1,214 @@
+package fdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+var errNotContiguous = errors.New("not contiguous")
+
+// The bytes of a hash table within its file: every bucket,
+// row, and value, and the position of every pointer to them.
+type hashTableLayout struct {
+	ranges   [][2]uint32
+	pointers []uint32
+
+	rows map[uint32]struct{}
+	data map[uint32]struct{}
+}
+
+func readUint32s(r io.ReadSeeker, offset uint32, n int) ([]uint32, error) {
+	if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, n*4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = order.Uint32(data[i*4:])
+	}
+	return values, nil
+}
+
+func (l *hashTableLayout) add(offset, size uint32) {
+	l.ranges = append(l.ranges, [2]uint32{offset, offset + size})
+}
+
+func (l *hashTableLayout) addValue(r io.ReadSeeker, variant Variant, offset uint32) error {
+	if _, ok := l.data[offset]; ok {
+		return nil
+	}
+	l.data[offset] = struct{}{}
+
+	switch variant {
+	case VariantNVarChar, VariantText:
+		if _, err := r.Seek(int64(offset), io.SeekStart); err != nil {
+			return err
+		}
+
+		b, err := readNullTerminatedBytes(r)
+		if err != nil {
+			return err
+		}
+		l.add(offset, uint32(len(b))+1)
+	default:
+		l.add(offset, 8)
+	}
+	return nil
+}
+
+func (l *hashTableLayout) addRow(r io.ReadSeeker, offset uint32) error {
+	if _, ok := l.rows[offset]; ok {
+		return nil
+	}
+	l.rows[offset] = struct{}{}
+
+	header, err := readUint32s(r, offset, 2)
+	if err != nil {
+		return err
+	}
+	l.add(offset, 8)
+
+	numColumns, arrayOffset := int(header[0]), header[1]
+	if numColumns == 0 {
+		return nil
+	}
+	l.pointers = append(l.pointers, offset+4)
+
+	entries, err := readUint32s(r, arrayOffset, numColumns*2)
+	if err != nil {
+		return err
+	}
+	l.add(arrayOffset, uint32(numColumns)*8)
+
+	for i := range numColumns {
+		variant, data := Variant(entries[i*2]), entries[i*2+1]
+		switch variant {
+		case VariantNull, VariantI32, VariantU32, VariantReal, VariantBool:
+		case VariantNVarChar, VariantText, VariantI64, VariantU64:
+			l.pointers = append(l.pointers, arrayOffset+uint32(i)*8+4)
+			if err := l.addValue(r, variant, data); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown variant: %v", variant)
+		}
+	}
+	return nil
+}
+
+// Walks every bucket of the hash table.
+func readHashTableLayout(h *HashTable) (*hashTableLayout, error) {
+	l := &hashTableLayout{
+		rows: make(map[uint32]struct{}),
+		data: make(map[uint32]struct{}),
+	}
+
+	base := uint32(h.base)
+	l.add(base, uint32(h.numBuckets)*4)
+
+	buckets, err := readUint32s(h.r, base, h.numBuckets)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint32]struct{})
+	for i, node := range buckets {
+		if node == noData {
+			continue
+		}
+		l.pointers = append(l.pointers, base+uint32(i)*4)
+
+		for node != noData {
+			if _, ok := nodes[node]; ok {
+				return nil, fmt.Errorf("bucket %d: cycle at %d", i, node)
+			}
+			nodes[node] = struct{}{}
+
+			link, err := readUint32s(h.r, node, 2)
+			if err != nil {
+				return nil, err
+			}
+			l.add(node, 8)
+
+			l.pointers = append(l.pointers, node)
+			if err := l.addRow(h.r, link[0]); err != nil {
+				return nil, err
+			}
+
+			if link[1] != noData {
+				l.pointers = append(l.pointers, node+4)
+			}
+			node = link[1]
+		}
+	}
+
+	return l, nil
+}
+
+// Returns the contiguous span of bytes containing the hash table. Gaps
+// smaller than 4 bytes are alignment padding and are part of the span.
+func (l *hashTableLayout) span() (start, end uint32, err error) {
+	slices.SortFunc(l.ranges, func(a, b [2]uint32) int {
+		return int(int64(a[0]) - int64(b[0]))
+	})
+
+	start, end = l.ranges[0][0], l.ranges[0][1]
+	for _, r := range l.ranges[1:] {
+		if r[0] > end+3 {
+			return 0, 0, errNotContiguous
+		}
+		end = max(end, r[1])
+	}
+	return start, end, nil
+}
+
+// Copies the hash table's bytes as-is, only relocating its pointers.
+// If the hash table's bytes are not contiguous, for example because
+// it shares values with another table, copyHashTable returns false.
+func copyHashTable(w *writer, h *HashTable) (bool, error) {
+	layout, err := readHashTableLayout(h)
+	if err != nil {
+		return false, err
+	}
+
+	start, end, err := layout.span()
+	if errors.Is(err, errNotContiguous) {
+		return false, nil
+	}
+
+	data := make([]byte, (end-start+3)&^3)
+	if _, err := h.r.Seek(int64(start), io.SeekStart); err != nil {
+		return false, err
+	}
+
+	if _, err := io.ReadFull(h.r, data[:end-start]); err != nil {
+		return false, err
+	}
+
+	newStart := w.Pos() + 8
+	for _, pos := range layout.pointers {
+		p := data[pos-start:]
+		order.PutUint32(p, order.Uint32(p)-start+newStart)
+	}
+
+	if err := w.PutUint32(uint32(h.numBuckets)); err != nil {
+		return false, err
+	}
+
+	if err := w.PutUint32(uint32(h.base) - start + newStart); err != nil {
+		return false, err
+	}
+
+	if err := w.PutBytes(data); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
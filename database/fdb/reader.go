@@ -8,13 +8,13 @@ import (
 )
 
 type Column struct {
-	Variant Variant
-	Name    string
+	Variant Variant `json:"variant"`
+	Name    string  `json:"name"`
 }
 
 type Table struct {
-	Name    string
-	Columns []*Column
+	Name    string    `json:"name"`
+	Columns []*Column `json:"columns"`
 
 	hashTable *HashTable
 }
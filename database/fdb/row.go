@@ -43,7 +43,8 @@ func (r Row) Value(i int) (any, error) {
 }
 
 // Returns an int representing the first [Entry]
-// of the row.
+// of the row. Use [Row.Key] for the value used to
+// locate the row's bucket.
 //
 // If the variant is a
 //
@@ -101,3 +102,78 @@ func (r *Row) Id() (int, error) {
 		return 0, fmt.Errorf("cannot read id for %s", entry.Variant())
 	}
 }
+
+// Returns the hash of the first [Entry] of the row. The row is
+// stored within the bucket at the index: Key % the # of buckets.
+//
+// If the variant is a
+//
+//   - [VariantI32], Key returns the value's two's complement bits,
+//     so negative values hash to large keys.
+//   - [VariantI64] or [VariantU64], Key returns the lower 32 bits
+//     of the value.
+//   - [VariantReal], Key returns the float32's bits.
+//   - [VariantNVarChar] or [VariantText], Key returns the [Sfhash]
+//     of the string's bytes.
+//   - [VariantBool], Key returns 1 if true and 0 if false.
+//
+// Key returns an error if the variant is unrecognized or if the
+// the variant is equal to [VariantNull].
+func (r Row) Key() (uint32, error) {
+	if len(r) == 0 {
+		panic(fmt.Errorf("fdb: row: key: no entries"))
+	}
+	return entryKey(r[0])
+}
+
+func entryKey(entry Entry) (uint32, error) {
+	switch entry.Variant() {
+	case VariantNull:
+		return 0, fmt.Errorf("row key: %w", ErrNullData)
+	case VariantI32:
+		return uint32(entry.Int32()), nil
+	case VariantU32:
+		return entry.Uint32(), nil
+	case VariantReal:
+		return math.Float32bits(entry.Float32()), nil
+	case VariantBool:
+		if entry.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case VariantI64:
+		v, err := entry.Int64()
+		if err != nil {
+			return 0, err
+		}
+		return uint32(v), nil
+	case VariantU64:
+		v, err := entry.Uint64()
+		if err != nil {
+			return 0, err
+		}
+		return uint32(v), nil
+	case VariantNVarChar, VariantText:
+		s, err := entry.String()
+		if err != nil {
+			return 0, err
+		}
+		return Sfhash([]byte(s)), nil
+	default:
+		return 0, fmt.Errorf("cannot read key for %s", entry.Variant())
+	}
+}
+
+// Returns a copy of the row where each [Entry] is
+// a [*DataEntry].
+func (r Row) copy() (Row, error) {
+	row := make(Row, len(r))
+	for i, entry := range r {
+		c, err := copyEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("copy: %d: %v", i, err)
+		}
+		row[i] = c
+	}
+	return row, nil
+}
@@ -0,0 +1,263 @@
+package fdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrMissingTable     = errors.New("missing table")
+	ErrUnexpectedTable  = errors.New("unexpected table")
+	ErrMissingColumn    = errors.New("missing column")
+	ErrUnexpectedColumn = errors.New("unexpected column")
+	ErrColumnOrder      = errors.New("column order")
+	ErrVariantMismatch  = errors.New("variant mismatch")
+	ErrEntryMismatch    = errors.New("entry mismatch")
+)
+
+// Describes a single difference between a [Schema] and
+// a database. Column is empty if the error applies to
+// the whole table.
+type SchemaError struct {
+	Table  string
+	Column string
+	Err    error
+	Detail string
+}
+
+func (e *SchemaError) Error() string {
+	s := strings.Builder{}
+	s.WriteString(e.Table)
+	if len(e.Column) > 0 {
+		s.WriteString(".")
+		s.WriteString(e.Column)
+	}
+	s.WriteString(": ")
+	s.WriteString(e.Err.Error())
+	if len(e.Detail) > 0 {
+		s.WriteString(": ")
+		s.WriteString(e.Detail)
+	}
+	return s.String()
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// The table and column definitions of a database.
+// A Schema can be encoded as JSON with [WriteSchema]
+// and decoded with [ReadSchema].
+type Schema struct {
+	Tables []*Table `json:"tables"`
+}
+
+// Returns the [*Schema] of the tables within the provided [*Reader].
+func SchemaOf(r *Reader) *Schema {
+	s := &Schema{Tables: make([]*Table, len(r.Tables()))}
+	for i, table := range r.Tables() {
+		columns := make([]*Column, len(table.Columns))
+		for j, column := range table.Columns {
+			c := *column
+			columns[j] = &c
+		}
+
+		s.Tables[i] = &Table{
+			Name:    table.Name,
+			Columns: columns,
+		}
+	}
+	return s
+}
+
+func (s Schema) FindTable(name string) (*Table, bool) {
+	for _, table := range s.Tables {
+		if table.Name == name {
+			return table, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the variant of the named column. If the table or
+// column does not exist within the schema, Variant returns false.
+func (s Schema) Variant(tableName, columnName string) (Variant, bool) {
+	table, ok := s.FindTable(tableName)
+	if !ok {
+		return VariantNull, false
+	}
+
+	for _, column := range table.Columns {
+		if column.Name == columnName {
+			return column.Variant, true
+		}
+	}
+	return VariantNull, false
+}
+
+func (s Schema) validateColumns(expected, actual *Table) []*SchemaError {
+	errs := []*SchemaError{}
+
+	names := []string{}
+	for _, column := range expected.Columns {
+		i := slices.IndexFunc(actual.Columns, func(c *Column) bool { return c.Name == column.Name })
+		if i < 0 {
+			errs = append(errs, &SchemaError{Table: expected.Name, Column: column.Name, Err: ErrMissingColumn})
+			continue
+		}
+		names = append(names, column.Name)
+
+		if variant := actual.Columns[i].Variant; variant != column.Variant {
+			errs = append(errs, &SchemaError{Table: expected.Name, Column: column.Name, Err: ErrVariantMismatch, Detail: fmt.Sprintf("expected %v but got %v", column.Variant, variant)})
+		}
+	}
+
+	actualNames := []string{}
+	for _, column := range actual.Columns {
+		if slices.ContainsFunc(expected.Columns, func(c *Column) bool { return c.Name == column.Name }) {
+			actualNames = append(actualNames, column.Name)
+		} else {
+			errs = append(errs, &SchemaError{Table: expected.Name, Column: column.Name, Err: ErrUnexpectedColumn})
+		}
+	}
+
+	if !slices.Equal(names, actualNames) {
+		errs = append(errs, &SchemaError{Table: expected.Name, Err: ErrColumnOrder, Detail: fmt.Sprintf("expected (%s) but got (%s)", strings.Join(names, ", "), strings.Join(actualNames, ", "))})
+	}
+
+	return errs
+}
+
+func (s Schema) validateRows(expected, actual *Table, rows RowsFunc) ([]*SchemaError, error) {
+	variants := make([]Variant, len(actual.Columns))
+	for i, column := range actual.Columns {
+		variant, ok := s.Variant(expected.Name, column.Name)
+		if !ok {
+			variant = VariantNull
+		}
+		variants[i] = variant
+	}
+
+	type mismatch struct {
+		count   int
+		firstId int
+	}
+	mismatches := make(map[[2]int]*mismatch)
+
+	for row, err := range rows(actual.Name) {
+		if err != nil {
+			return nil, err
+		}
+
+		for i, entry := range row {
+			if i >= len(variants) || variants[i] == VariantNull || entry.Variant() == VariantNull || entry.Variant() == variants[i] {
+				continue
+			}
+
+			key := [2]int{i, int(entry.Variant())}
+			m, ok := mismatches[key]
+			if !ok {
+				id, _ := row.Id()
+				m = &mismatch{firstId: id}
+				mismatches[key] = m
+			}
+			m.count++
+		}
+	}
+
+	keys := make([][2]int, 0, len(mismatches))
+	for key := range mismatches {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return a[1] - b[1]
+	})
+
+	errs := []*SchemaError{}
+	for _, key := range keys {
+		m := mismatches[key]
+		errs = append(errs, &SchemaError{
+			Table:  expected.Name,
+			Column: actual.Columns[key[0]].Name,
+			Err:    ErrEntryMismatch,
+			Detail: fmt.Sprintf("%d row(s) contain %v entries instead of %v (first row id: %d)", m.count, Variant(key[1]), variants[key[0]], m.firstId),
+		})
+	}
+
+	return errs, nil
+}
+
+// Compares the tables against the schema and returns every
+// difference found. If rows is not nil, each row is also checked
+// for entries whose variant does not match the schema's column
+// variant. [VariantNull] entries are always accepted.
+//
+// The returned error is only non-nil if reading the rows failed.
+func (s Schema) Validate(tables []*Table, rows RowsFunc) ([]*SchemaError, error) {
+	errs := []*SchemaError{}
+
+	for _, expected := range s.Tables {
+		i := slices.IndexFunc(tables, func(t *Table) bool { return t.Name == expected.Name })
+		if i < 0 {
+			errs = append(errs, &SchemaError{Table: expected.Name, Err: ErrMissingTable})
+			continue
+		}
+		actual := tables[i]
+
+		errs = append(errs, s.validateColumns(expected, actual)...)
+
+		if rows == nil {
+			continue
+		}
+
+		rowErrs, err := s.validateRows(expected, actual, rows)
+		if err != nil {
+			return nil, fmt.Errorf("fdb: validate: %s: %v", actual.Name, err)
+		}
+		errs = append(errs, rowErrs...)
+	}
+
+	for _, table := range tables {
+		if _, ok := s.FindTable(table.Name); !ok {
+			errs = append(errs, &SchemaError{Table: table.Name, Err: ErrUnexpectedTable})
+		}
+	}
+
+	return errs, nil
+}
+
+// Validates the tables and rows of the provided [*Reader].
+// See [Schema.Validate] for details.
+func (s Schema) ValidateReader(r *Reader) ([]*SchemaError, error) {
+	return s.Validate(r.Tables(), func(tableName string) iter.Seq2[Row, error] {
+		table, _ := r.FindTable(tableName)
+		return table.Rows()
+	})
+}
+
+// Reads a JSON encoded [*Schema].
+func ReadSchema(r io.Reader) (*Schema, error) {
+	s := &Schema{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("fdb: read schema: %v", err)
+	}
+	return s, nil
+}
+
+// Writes the [*Schema] as indented JSON.
+func WriteSchema(w io.Writer, s *Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return fmt.Errorf("fdb: write schema: %v", err)
+	}
+	return nil
+}
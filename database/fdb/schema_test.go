@@ -0,0 +1,137 @@
+package fdb_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/database/fdb"
+)
+
+func TestSchema(t *testing.T) {
+	reader, err := fdb.OpenReader(filepath.Join("testdata", "basic.fdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	buf := bytes.Buffer{}
+	if err := fdb.WriteSchema(&buf, fdb.SchemaOf(reader)); err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := fdb.ReadSchema(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range reader.Tables() {
+		actual, ok := schema.FindTable(expected.Name)
+		if !ok {
+			t.Errorf("schema does not contain table %s", expected.Name)
+			continue
+		}
+		checkTable(t, expected, actual)
+	}
+
+	errs, err := schema.ValidateReader(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, err := range errs {
+		t.Errorf("unexpected schema error: %v", err)
+	}
+
+	t.Run("mismatched", func(t *testing.T) {
+		schema := &fdb.Schema{Tables: []*fdb.Table{
+			{Name: "Accounts", Columns: []*fdb.Column{{fdb.VariantU32, "id"}, {fdb.VariantU32, "age"}, {fdb.VariantNVarChar, "name"}, {fdb.VariantI32, "isActive"}, {fdb.VariantText, "email"}}},
+			{Name: "Skills", Columns: []*fdb.Column{{fdb.VariantU32, "accountId"}, {fdb.VariantText, "skillName"}}},
+			{Name: "Version", Columns: []*fdb.Column{{fdb.VariantI32, "major"}, {fdb.VariantI32, "minor"}, {fdb.VariantI32, "patch"}}},
+			{Name: "Missing", Columns: []*fdb.Column{{fdb.VariantI32, "id"}}},
+		}}
+
+		errs, err := schema.ValidateReader(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := map[string]error{
+			"Accounts.isActive": fdb.ErrVariantMismatch,
+			"Accounts.email":    fdb.ErrMissingColumn,
+			"Accounts":          fdb.ErrColumnOrder,
+			"Skills.power":      fdb.ErrUnexpectedColumn,
+			"Missing":           fdb.ErrMissingTable,
+			"NPCs":              fdb.ErrUnexpectedTable,
+		}
+
+		found := map[string]bool{}
+		for _, err := range errs {
+			key := err.Table
+			if len(err.Column) > 0 {
+				key += "." + err.Column
+			}
+
+			if expectedErr, ok := expected[key]; ok && errors.Is(err, expectedErr) {
+				found[key] = true
+				continue
+			}
+
+			if key == "Accounts.isActive" && errors.Is(err, fdb.ErrEntryMismatch) {
+				continue
+			}
+
+			t.Errorf("unexpected schema error: %v", err)
+		}
+
+		for key, err := range expected {
+			if !found[key] {
+				t.Errorf("%s: expected %v", key, err)
+			}
+		}
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		dir, err := os.MkdirTemp("testdata", "fdb*.tmp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		tables := []*fdb.Table{
+			{Name: "Values", Columns: []*fdb.Column{{fdb.VariantI32, "id"}, {fdb.VariantI32, "value"}}},
+		}
+
+		fdbName := filepath.Join(dir, "entries.fdb")
+		if err := createTable(fdbName, tables, map[string][]fdb.Row{
+			"Values": {
+				{entry(fdb.VariantI32, int32(1)), entry(fdb.VariantI32, int32(5))},
+				{entry(fdb.VariantI32, int32(2)), entry(fdb.VariantNull, nil)},
+				{entry(fdb.VariantI32, int32(3)), entry(fdb.VariantNVarChar, "five")},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := fdb.OpenReader(fdbName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+
+		errs, err := (&fdb.Schema{Tables: tables}).ValidateReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(errs) != 1 {
+			t.Fatalf("expected 1 schema error but got %d: %v", len(errs), errs)
+		}
+
+		if !errors.Is(errs[0], fdb.ErrEntryMismatch) || errs[0].Column != "value" {
+			t.Errorf("expected entry mismatch for column value but got %v", errs[0])
+		}
+	})
+}
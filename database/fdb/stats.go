@@ -0,0 +1,77 @@
+package fdb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Describes the hash table of a single table.
+type TableStats struct {
+	Name string
+
+	Rows    int
+	Buckets int
+
+	// The number of buckets that do not contain any rows.
+	NullBuckets int
+
+	// The number of rows within the largest bucket.
+	LongestChain int
+}
+
+// Returns the average number of rows per bucket.
+func (s TableStats) LoadFactor() float64 {
+	if s.Buckets == 0 {
+		return 0
+	}
+	return float64(s.Rows) / float64(s.Buckets)
+}
+
+// Returns the [TableStats] of the table's hash table.
+func (t Table) Stats() (TableStats, error) {
+	stats := TableStats{Name: t.Name}
+	if t.hashTable == nil {
+		return stats, nil
+	}
+
+	stats.Buckets = t.hashTable.numBuckets
+	for i := range stats.Buckets {
+		bucket, err := t.hashTable.Bucket(i)
+		if errors.Is(err, ErrNullData) {
+			stats.NullBuckets++
+			continue
+		}
+
+		if err != nil {
+			return stats, fmt.Errorf("bucket %d: %v", i, err)
+		}
+
+		chain := 0
+		for bucket.Next() {
+			chain++
+		}
+
+		if err := bucket.Err(); err != nil {
+			return stats, fmt.Errorf("bucket %d: %v", i, err)
+		}
+
+		stats.Rows += chain
+		stats.LongestChain = max(stats.LongestChain, chain)
+	}
+
+	return stats, nil
+}
+
+// Returns the [TableStats] of every table within r by
+// walking each bucket of the tables' hash tables.
+func Stats(r *Reader) ([]TableStats, error) {
+	stats := make([]TableStats, 0, len(r.Tables()))
+	for _, table := range r.Tables() {
+		s, err := table.Stats()
+		if err != nil {
+			return nil, fmt.Errorf("fdb: stats: %s: %v", table.Name, err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
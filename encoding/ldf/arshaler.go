@@ -1,28 +1,37 @@
 package ldf
 
 import (
+	"bytes"
 	"encoding"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 )
 
 var (
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
 	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
-	string16Type        = reflect.TypeFor[String16]()
+	marshalerType       = reflect.TypeFor[Marshaler]()
+	unmarshalerType     = reflect.TypeFor[Unmarshaler]()
+	rawValueType        = reflect.TypeFor[RawValue]()
 )
 
 type (
-	textMarshalerFunc   func(d *TextEncoder, value reflect.Value) error
-	textUnmarshalerFunc func(d *TextDecoder, value reflect.Value, seq TokenSeq) error
+	marshalerFunc   func(enc tokenWriter, value reflect.Value) error
+	unmarshalerFunc func(dec *decodeState, value reflect.Value, seq TokenSeq) error
 
 	tokenMap = map[string]Token
 )
 
+// Shared by the text and binary encodings, which
+// only differ in how each [Token] is written and read.
 type arshaler struct {
-	textMarshal   textMarshalerFunc
-	textUnmarshal textUnmarshalerFunc
+	marshal   marshalerFunc
+	unmarshal unmarshalerFunc
 }
 
 var arshalerMap sync.Map // map[reflect.Type]*arshaler
@@ -46,16 +55,28 @@ func makeArshaler(t reflect.Type) *arshaler {
 		return makeMapArshaler(t)
 	default:
 		return &arshaler{
-			textMarshal: func(enc *TextEncoder, value reflect.Value) error {
+			marshal: func(enc tokenWriter, value reflect.Value) error {
 				return fmt.Errorf("cannot encode %v", t)
 			},
-			textUnmarshal: func(*TextDecoder, reflect.Value, TokenSeq) error {
+			unmarshal: func(*decodeState, reflect.Value, TokenSeq) error {
 				return fmt.Errorf("cannot decode %v", t)
 			},
 		}
 	}
 }
 
+// Returns an arshaler which always fails with err.
+func makeInvalidArshaler(err error) *arshaler {
+	return &arshaler{
+		marshal: func(tokenWriter, reflect.Value) error {
+			return err
+		},
+		unmarshal: func(*decodeState, reflect.Value, TokenSeq) error {
+			return err
+		},
+	}
+}
+
 func isEmpty(v reflect.Value) bool {
 	if v.IsZero() {
 		return true
@@ -67,12 +88,20 @@ func isEmpty(v reflect.Value) bool {
 	return false
 }
 
-func getUnmarshaler(v reflect.Value) (reflect.Value, bool) {
-	if v.Type().Implements(textUnmarshalerType) {
+func isString16(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint16
+}
+
+func implementsMarshaler(t reflect.Type) bool {
+	return t.Implements(marshalerType) || t.Implements(textMarshalerType)
+}
+
+func getUnmarshaler(v reflect.Value, iface reflect.Type) (reflect.Value, bool) {
+	if v.Type().Implements(iface) {
 		return v, true
 	}
 
-	if addr := v.Addr(); addr.Type().Implements(textUnmarshalerType) {
+	if addr := v.Addr(); addr.Type().Implements(iface) {
 		return addr, true
 	}
 	return reflect.Value{}, false
@@ -103,9 +132,80 @@ type fieldInfo struct {
 	goType    reflect.Type
 	omitEmpty bool
 	raw       bool
+
+	// Set by the "type=N" option.
+	hasType   bool
+	valueType ValueType
+
+	// Nested struct fields are flattened like embedded structs,
+	// with their keys prefixed by the field's tag name, if any.
+	nested bool
+	prefix string
+
+	// Slices are encoded as indexed keys: name0, name1, etc.
+	indexed bool
+}
+
+// Reports whether values of t are encoded as a single value,
+// rather than being flattened or indexed.
+func isValue(t reflect.Type) bool {
+	return t == rawValueType || implementsMarshaler(t) ||
+		implementsMarshaler(reflect.PointerTo(t)) ||
+		reflect.PointerTo(t).Implements(unmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func isNested(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isValue(t)
+}
+
+func isIndexed(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || isValue(t) {
+		return false
+	}
+
+	elemKind := t.Elem().Kind()
+	return elemKind != reflect.Uint8 && elemKind != reflect.Uint16
+}
+
+func setInt(field reflect.Value, v int64) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.OverflowInt(v) {
+			return fmt.Errorf("%d overflows %v", v, field.Type())
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v < 0 || field.OverflowUint(uint64(v)) {
+			return fmt.Errorf("%d overflows %v", v, field.Type())
+		}
+		field.SetUint(uint64(v))
+	default:
+		return fmt.Errorf("cannot decode integer into %v", field.Type())
+	}
+	return nil
+}
+
+func setUint(field reflect.Value, v uint64) error {
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if field.OverflowUint(v) {
+			return fmt.Errorf("%d overflows %v", v, field.Type())
+		}
+		field.SetUint(v)
+	default:
+		if v > math.MaxInt64 {
+			return fmt.Errorf("%d overflows %v", v, field.Type())
+		}
+		return setInt(field, int64(v))
+	}
+	return nil
 }
 
-func setStructField(field reflect.Value, fieldInfo fieldInfo, decodedValue any) (err error) {
+func setStructField(field reflect.Value, decodedValue any) (err error) {
 	defer func() {
 		if r := recover(); err == nil && r != nil {
 			err = fmt.Errorf("%v", r)
@@ -114,33 +214,39 @@ func setStructField(field reflect.Value, fieldInfo fieldInfo, decodedValue any)
 
 	switch v := decodedValue.(type) {
 	case string:
-		if fieldInfo.goType == string16Type {
-			field.Set(reflect.ValueOf(ToString16(v)))
-		} else {
+		switch {
+		case isString16(field.Type()):
+			field.Set(reflect.ValueOf(ToString16(v)).Convert(field.Type()))
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8:
+			field.SetBytes([]byte(v))
+		default:
 			field.SetString(v)
 		}
 	case int32:
-		field.SetInt(int64(v))
+		return setInt(field, int64(v))
 	case int64:
-		field.SetInt(v)
+		return setInt(field, v)
 	case int:
-		field.SetInt(int64(v))
+		return setInt(field, int64(v))
 	case float32:
 		field.SetFloat(float64(v))
 	case float64:
 		field.SetFloat(v)
 	case uint32:
-		field.SetUint(uint64(v))
+		return setUint(field, uint64(v))
 	case uint64:
-		field.SetUint(v)
+		return setUint(field, v)
 	case uint:
-		field.SetUint(uint64(v))
+		return setUint(field, uint64(v))
 	case bool:
 		field.SetBool(v)
 	case []uint8:
-		if fieldInfo.goType.Kind() == reflect.String {
+		switch {
+		case field.Kind() == reflect.String:
 			field.SetString(string(v))
-		} else {
+		case isString16(field.Type()):
+			field.Set(reflect.ValueOf(ToString16(string(v))).Convert(field.Type()))
+		default:
 			field.SetBytes(v)
 		}
 	default:
@@ -149,6 +255,155 @@ func setStructField(field reflect.Value, fieldInfo fieldInfo, decodedValue any)
 	return nil
 }
 
+// Encodes a single value of the field, applying the "raw" and "type=N" options.
+func (fieldInfo fieldInfo) encode(value reflect.Value) (ValueType, string, error) {
+	valueType, encodedValue, err := encodeValue(value)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if fieldInfo.raw {
+		valueType = ValueTypeUtf8
+	}
+
+	if fieldInfo.hasType {
+		encodedValue, err = convertValue(encodedValue, valueType, fieldInfo.valueType)
+		if err != nil {
+			return 0, "", err
+		}
+		valueType = fieldInfo.valueType
+	}
+
+	return valueType, encodedValue, nil
+}
+
+// Decodes a single token into value, allocating pointers as needed.
+func (fieldInfo fieldInfo) decode(value reflect.Value, token Token) error {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if value.Type() == rawValueType {
+		value.Set(reflect.ValueOf(RawValue{token.Type, bytes.Clone(token.Value)}))
+		return nil
+	}
+
+	if v, ok := getUnmarshaler(value, unmarshalerType); ok {
+		return v.Interface().(Unmarshaler).UnmarshalLDF(token.Type, token.Value)
+	}
+
+	if v, ok := getUnmarshaler(value, textUnmarshalerType); ok && (token.Type == ValueTypeString || token.Type == ValueTypeUtf8) {
+		return v.Interface().(encoding.TextUnmarshaler).UnmarshalText(token.Value)
+	}
+
+	decodedValue, err := token.Interface()
+	if err != nil {
+		return err
+	}
+
+	if value.Kind() == reflect.Interface && value.NumMethod() == 0 {
+		value.Set(reflect.ValueOf(decodedValue))
+		return nil
+	}
+
+	return setStructField(value, decodedValue)
+}
+
+// Prefixes every key written to w.
+type prefixWriter struct {
+	w      tokenWriter
+	prefix string
+}
+
+func (w prefixWriter) write(key string, valueType ValueType, value string) error {
+	return w.w.write(w.prefix+key, valueType, value)
+}
+
+func (fieldInfo fieldInfo) marshalNested(enc tokenWriter, field reflect.Value) error {
+	if field.Kind() != reflect.Struct && field.Kind() != reflect.Map {
+		return nil
+	}
+
+	if len(fieldInfo.prefix) > 0 {
+		enc = prefixWriter{enc, fieldInfo.prefix}
+	}
+
+	marshal := getArshaler(field.Type()).marshal
+	if err := marshal(enc, field); err != nil {
+		return fmt.Errorf("%v: %v", field.Type(), err)
+	}
+	return nil
+}
+
+func (fieldInfo fieldInfo) unmarshalNested(dec *decodeState, field reflect.Value, tokens tokenMap) error {
+	target := field
+	if field.Kind() == reflect.Pointer {
+		target = reflect.New(field.Type().Elem()).Elem()
+		if !field.IsNil() {
+			target.Set(field.Elem())
+		}
+	}
+
+	if target.Kind() != reflect.Struct && target.Kind() != reflect.Map {
+		return nil
+	}
+
+	inner := dec
+	seq := toTokenSeq(tokens, dec.tokensDecoded)
+	if len(fieldInfo.prefix) > 0 {
+		inner = newDecodeState()
+		inner.lax = dec.lax
+		seq = func(yield func(Token) bool) {
+			for token := range toTokenSeq(tokens, dec.tokensDecoded) {
+				key, ok := strings.CutPrefix(token.Key, fieldInfo.prefix)
+				if !ok {
+					continue
+				}
+
+				inner.positions[key] = dec.positions[token.Key]
+				token.Key = key
+				if !yield(token) {
+					return
+				}
+			}
+		}
+	}
+
+	numDecoded := len(dec.tokensDecoded)
+
+	unmarshal := getArshaler(target.Type()).unmarshal
+	err := unmarshal(inner, target, seq)
+
+	if inner != dec {
+		for key := range inner.tokensDecoded {
+			dec.tokensDecoded[fieldInfo.prefix+key] = struct{}{}
+		}
+
+		for _, decodeErr := range inner.errs {
+			decodeErr.Key = fieldInfo.prefix + decodeErr.Key
+			dec.errs = append(dec.errs, decodeErr)
+		}
+
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			decodeErr.Key = fieldInfo.prefix + decodeErr.Key
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Pointers are only set if any of their keys were decoded.
+	if field.Kind() == reflect.Pointer && len(dec.tokensDecoded) > numDecoded {
+		field.Set(target.Addr())
+	}
+	return nil
+}
+
 func makeStructArshaler(t reflect.Type) *arshaler {
 	fields := []fieldInfo{}
 
@@ -158,7 +413,8 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 		tag := f.Tag.Get("ldf")
 
 		name, options, _ := strings.Cut(tag, ",")
-		if len(name) == 0 {
+		hasName := len(name) > 0
+		if !hasName {
 			name = f.Name
 		}
 
@@ -168,6 +424,16 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 			ignore:   name == "-" || !(f.IsExported() || f.Anonymous),
 			goType:   f.Type,
 		}
+
+		if !fieldInfo.embedded && isNested(f.Type) {
+			fieldInfo.nested = true
+			if hasName {
+				fieldInfo.prefix = name
+			}
+		}
+
+		fieldInfo.indexed = isIndexed(f.Type)
+
 		if !fieldInfo.ignore {
 			for len(options) > 0 {
 				var option string
@@ -178,16 +444,33 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 					fieldInfo.omitEmpty = true
 				case "raw":
 					// "raw" option can't be applied to String16's
-					fieldInfo.raw = t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint16
+					fieldInfo.raw = !isString16(f.Type)
+				case "utf16":
+					fieldInfo.hasType = true
+					fieldInfo.valueType = ValueTypeString
+				}
+
+				if typeOption, ok := strings.CutPrefix(option, "type="); ok {
+					valueType, err := strconv.ParseUint(typeOption, 10, 8)
+					if err != nil || valueType > uint64(ValueTypeUtf8) {
+						return makeInvalidArshaler(fmt.Errorf("%v.%s: invalid value type: %s", t, f.Name, typeOption))
+					}
+
+					fieldInfo.hasType = true
+					fieldInfo.valueType = ValueType(valueType)
 				}
 			}
+
+			if fieldInfo.raw && fieldInfo.hasType {
+				return makeInvalidArshaler(fmt.Errorf("%v.%s: raw cannot be combined with utf16 or type", t, f.Name))
+			}
 		}
 
 		fields = append(fields, fieldInfo)
 	}
 
 	return &arshaler{
-		textMarshal: func(enc *TextEncoder, structValue reflect.Value) error {
+		marshal: func(enc tokenWriter, structValue reflect.Value) error {
 			for i, fieldInfo := range fields {
 				if fieldInfo.ignore {
 					continue
@@ -198,33 +481,50 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 					continue
 				}
 
-				if fieldInfo.embedded {
-					if field.Kind() == reflect.Struct || field.Kind() == reflect.Map {
-						marshal := getArshaler(field.Type()).textMarshal
-						if err := marshal(enc, field); err != nil {
-							return fmt.Errorf("%v: %v", field.Type(), err)
-						}
+				// Nil pointers and interfaces are omitted.
+				if field.Kind() == reflect.Pointer || field.Kind() == reflect.Interface {
+					if field.IsNil() {
+						continue
+					}
+					field = field.Elem()
+				}
+
+				if fieldInfo.embedded || fieldInfo.nested {
+					if err := fieldInfo.marshalNested(enc, field); err != nil {
+						return err
 					}
 					continue
 				}
 
-				valueType, encodedValue, err := enc.encodeValue(field)
-				if err != nil {
-					return fmt.Errorf("%s: %v", fieldInfo.name, err)
+				if fieldInfo.indexed {
+					for j := range field.Len() {
+						key := fieldInfo.name + strconv.Itoa(j)
+
+						valueType, encodedValue, err := fieldInfo.encode(field.Index(j))
+						if err != nil {
+							return fmt.Errorf("%s: %w", key, err)
+						}
+
+						if err := enc.write(key, valueType, encodedValue); err != nil {
+							return fmt.Errorf("%s: %w", key, err)
+						}
+					}
+					continue
 				}
 
-				if fieldInfo.raw {
-					valueType = ValueTypeUtf8
+				valueType, encodedValue, err := fieldInfo.encode(field)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fieldInfo.name, err)
 				}
 
 				if err := enc.write(fieldInfo.name, valueType, encodedValue); err != nil {
-					return fmt.Errorf("%s: %v", fieldInfo.name, err)
+					return fmt.Errorf("%s: %w", fieldInfo.name, err)
 				}
 			}
 
 			return nil
 		},
-		textUnmarshal: func(dec *TextDecoder, value reflect.Value, seq TokenSeq) (err error) {
+		unmarshal: func(dec *decodeState, value reflect.Value, seq TokenSeq) (err error) {
 			defer func() {
 				if r := recover(); err == nil && r != nil {
 					err = fmt.Errorf("%v", r)
@@ -240,43 +540,51 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 
 				field := value.Field(i)
 
-				if fieldInfo.embedded {
-					unmarshal := getArshaler(fieldInfo.goType).textUnmarshal
-					if err := unmarshal(dec, field, toTokenSeq(tokens, dec.tokensDecoded)); err != nil {
-						return fmt.Errorf("%s: %v", fieldInfo.goType, err)
+				if fieldInfo.embedded || fieldInfo.nested {
+					if err := fieldInfo.unmarshalNested(dec, field, tokens); err != nil {
+						return err
 					}
 					continue
 				}
 
-				token, ok := tokens[fieldInfo.name]
-				if !ok {
-					continue
-				}
+				if fieldInfo.indexed {
+					elems := reflect.MakeSlice(fieldInfo.goType, 0, 0)
+					for j := 0; ; j++ {
+						token, ok := tokens[fieldInfo.name+strconv.Itoa(j)]
+						if !ok {
+							break
+						}
 
-				if fieldInfo.goType.Kind() == reflect.Pointer {
-					elem := fieldInfo.goType.Elem()
-					if elem.Kind() != reflect.Slice {
-						field.Set(reflect.New(elem))
-					}
-				}
+						dec.tokensDecoded[token.Key] = struct{}{}
 
-				if v, ok := getUnmarshaler(field); ok && (token.Type == ValueTypeString || token.Type == ValueTypeUtf8) {
-					if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText(token.Value); err != nil {
-						return fmt.Errorf("%s: %v", fieldInfo.name, err)
+						elem := reflect.New(fieldInfo.goType.Elem()).Elem()
+						if err := fieldInfo.decode(elem, token); err != nil {
+							if err := dec.fail(token, err); err != nil {
+								return err
+							}
+							continue
+						}
+						elems = reflect.Append(elems, elem)
 					}
-					return nil
-				}
 
-				decodedValue, err := token.Interface()
-				if err != nil {
-					return err
+					if elems.Len() > 0 {
+						field.Set(elems)
+					}
+					continue
 				}
 
-				if err := setStructField(field, fieldInfo, decodedValue); err != nil {
-					return fmt.Errorf("%s: %v", fieldInfo.name, err)
+				token, ok := tokens[fieldInfo.name]
+				if !ok {
+					continue
 				}
 
 				dec.tokensDecoded[token.Key] = struct{}{}
+
+				if err := fieldInfo.decode(field, token); err != nil {
+					if err := dec.fail(token, err); err != nil {
+						return err
+					}
+				}
 			}
 			return nil
 		},
@@ -286,10 +594,10 @@ func makeStructArshaler(t reflect.Type) *arshaler {
 func makeMapArshaler(t reflect.Type) *arshaler {
 	if t.Key().Kind() != reflect.String {
 		return &arshaler{
-			textMarshal: func(enc *TextEncoder, value reflect.Value) error {
+			marshal: func(enc tokenWriter, value reflect.Value) error {
 				return fmt.Errorf("cannot encode %v: maps must have a string key", t)
 			},
-			textUnmarshal: func(*TextDecoder, reflect.Value, TokenSeq) error {
+			unmarshal: func(*decodeState, reflect.Value, TokenSeq) error {
 				return fmt.Errorf("cannot decode %v: maps must have a string key", t)
 			},
 		}
@@ -298,34 +606,47 @@ func makeMapArshaler(t reflect.Type) *arshaler {
 	elemType := t.Elem()
 
 	return &arshaler{
-		textMarshal: func(enc *TextEncoder, value reflect.Value) error {
+		marshal: func(enc tokenWriter, value reflect.Value) error {
 			iter := value.MapRange()
 
 			for iter.Next() {
 				key := iter.Key().String()
 				value := iter.Value()
 
-				valueType, encodedValue, err := enc.encodeAny(value.Interface())
+				valueType, encodedValue, err := encodeAny(value.Interface())
 				if err != nil {
-					return fmt.Errorf("%s: %v", key, err)
+					return fmt.Errorf("%s: %w", key, err)
 				}
 
 				if err := enc.write(iter.Key().String(), valueType, encodedValue); err != nil {
-					return fmt.Errorf("%s: %v", key, err)
+					return fmt.Errorf("%s: %w", key, err)
 				}
 			}
 
 			return nil
 		},
-		textUnmarshal: func(dec *TextDecoder, value reflect.Value, seq TokenSeq) error {
+		unmarshal: func(dec *decodeState, value reflect.Value, seq TokenSeq) error {
 			if value.IsNil() {
 				value.Set(reflect.MakeMap(t))
 			}
 
 			for token := range seq {
-				decodeValue, err := dec.decodeValue(token, elemType)
+				dec.tokensDecoded[token.Key] = struct{}{}
+
+				decodeValue, err := decodeValue(token, elemType)
+				if err == nil && !decodeValue.Type().AssignableTo(elemType) {
+					if decodeValue.Kind() == elemType.Kind() {
+						decodeValue = decodeValue.Convert(elemType)
+					} else {
+						err = fmt.Errorf("cannot decode %v into %v", token.Type, elemType)
+					}
+				}
+
 				if err != nil {
-					return fmt.Errorf("%s: %v", token.Key, err)
+					if err := dec.fail(token, err); err != nil {
+						return err
+					}
+					continue
 				}
 
 				value.SetMapIndex(reflect.ValueOf(token.Key), decodeValue)
@@ -0,0 +1,519 @@
+package ldf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf16"
+)
+
+var order = binary.LittleEndian
+
+// Encodes binary LDF. Values of the undocumented value types, such as
+// [ValueTypeUnknown2], cannot be encoded and return [ErrBinaryUnsupported].
+type BinaryEncoder struct {
+	w        io.Writer
+	buf      bytes.Buffer
+	count    uint32
+	compress bool
+}
+
+func NewBinaryEncoder(w io.Writer) *BinaryEncoder {
+	return &BinaryEncoder{w: w}
+}
+
+// Compresses each encoded value with zlib. The value is prefixed with a
+// u8 compression flag, followed by the u32 uncompressed size and the u32
+// compressed size.
+func (e *BinaryEncoder) UseCompression() {
+	e.compress = true
+}
+
+func (e *BinaryEncoder) Reset(w io.Writer) {
+	e.w = w
+	e.buf.Reset()
+	e.count = 0
+}
+
+func appendString16(buf []byte, s string) []byte {
+	for _, c := range utf16.Encode([]rune(s)) {
+		buf = order.AppendUint16(buf, c)
+	}
+	return buf
+}
+
+// Appends the binary form of the textual value.
+func appendBinaryValue(buf []byte, valueType ValueType, value string) ([]byte, error) {
+	switch valueType {
+	case ValueTypeString:
+		s := utf16.Encode([]rune(value))
+		buf = order.AppendUint32(buf, uint32(len(s)))
+		for _, c := range s {
+			buf = order.AppendUint16(buf, c)
+		}
+		return buf, nil
+	case ValueTypeI32:
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint32(buf, uint32(v)), nil
+	case ValueTypeFloat:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint32(buf, math.Float32bits(float32(v))), nil
+	case ValueTypeDouble:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint64(buf, math.Float64bits(v)), nil
+	case ValueTypeU32:
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint32(buf, uint32(v)), nil
+	case ValueTypeBool:
+		if value == "1" {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case ValueTypeU64:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint64(buf, v), nil
+	case ValueTypeI64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return order.AppendUint64(buf, uint64(v)), nil
+	case ValueTypeUtf8:
+		buf = order.AppendUint32(buf, uint32(len(value)))
+		return append(buf, value...), nil
+	default:
+		if valueType.isUnknown() {
+			return nil, fmt.Errorf("cannot encode %v: %w", valueType, ErrBinaryUnsupported)
+		}
+		return nil, fmt.Errorf("cannot encode %v", valueType)
+	}
+}
+
+func (e *BinaryEncoder) write(key string, valueType ValueType, value string) error {
+	keyLength := len(utf16.Encode([]rune(key))) * 2
+	if keyLength > math.MaxUint8 {
+		return fmt.Errorf("%s: key too long: %d bytes", key, keyLength)
+	}
+
+	buf := make([]byte, 0, 1+keyLength+1+len(value))
+	buf = append(buf, uint8(keyLength))
+	buf = appendString16(buf, key)
+	buf = append(buf, uint8(valueType))
+
+	buf, err := appendBinaryValue(buf, valueType, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	e.buf.Write(buf)
+	e.count++
+	return nil
+}
+
+func (e *BinaryEncoder) flush() error {
+	data := order.AppendUint32(nil, e.count)
+	data = append(data, e.buf.Bytes()...)
+
+	if e.compress {
+		compressed := bytes.Buffer{}
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(data); err != nil {
+			return err
+		}
+
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		header := []byte{1}
+		header = order.AppendUint32(header, uint32(len(data)))
+		header = order.AppendUint32(header, uint32(compressed.Len()))
+		data = append(header, compressed.Bytes()...)
+	}
+
+	_, err := e.w.Write(data)
+	return err
+}
+
+// Writes the binary LDF encoding of v. Each call to Encode writes a
+// complete LDF value, prefixed with its u32 number of keys.
+func (e *BinaryEncoder) Encode(v any) error {
+	e.buf.Reset()
+	e.count = 0
+
+	if err := encode(e, v); err != nil {
+		return fmt.Errorf("ldf: encode: %w", err)
+	}
+
+	if err := e.flush(); err != nil {
+		return fmt.Errorf("ldf: encode: %w", err)
+	}
+	return nil
+}
+
+// Decodes binary LDF. Tokens of the undocumented value types, such as
+// [ValueTypeUnknown2], have no known size. Their value is every byte
+// left in the current LDF value, and it is decoded as a [RawValue]
+// holding those bytes. Any keys after it are lost, and for uncompressed
+// values, the rest of the stream is consumed.
+type BinaryDecoder struct {
+	r        io.Reader
+	src      io.Reader
+	compress bool
+
+	// The number of tokens left in the current value,
+	// or -1 if the value's header has not been read.
+	remaining int64
+
+	// The number of bytes read from the current value,
+	// and the offset of the current token.
+	offset, tokenOffset int64
+
+	disallowUnknownKeys bool
+
+	err   error
+	token Token
+}
+
+// Counts the bytes read from r into n.
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+func NewBinaryDecoder(r io.Reader) *BinaryDecoder {
+	d := &BinaryDecoder{}
+	d.Reset(r)
+
+	return d
+}
+
+// Expects each value to be prefixed with a u8 compression flag.
+// See [BinaryEncoder.UseCompression].
+func (d *BinaryDecoder) UseCompression() {
+	d.compress = true
+}
+
+// See [TextDecoder.DisallowUnknownKeys].
+func (d *BinaryDecoder) DisallowUnknownKeys() {
+	d.disallowUnknownKeys = true
+}
+
+func (d *BinaryDecoder) Reset(r io.Reader) {
+	d.r = r
+	d.src = r
+	d.remaining = -1
+	d.err = nil
+}
+
+func (d *BinaryDecoder) decompress() (io.Reader, error) {
+	var compressed bool
+	if err := binary.Read(d.r, order, &compressed); err != nil {
+		return nil, err
+	}
+
+	if !compressed {
+		return d.r, nil
+	}
+
+	var uncompressedSize, compressedSize uint32
+	if err := binary.Read(d.r, order, &uncompressedSize); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Read(d.r, order, &compressedSize); err != nil {
+		return nil, err
+	}
+
+	zr, err := zlib.NewReader(io.LimitReader(d.r, int64(compressedSize)))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data := bytes.Buffer{}
+	if _, err := io.CopyN(&data, zr, int64(uncompressedSize)); err != nil {
+		return nil, fmt.Errorf("decompress: %v", err)
+	}
+
+	// Reading to the end of the stream verifies its checksum.
+	if n, err := zr.Read(make([]byte, 1)); n > 0 || !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decompress: expected %d bytes", uncompressedSize)
+	}
+
+	return &data, nil
+}
+
+func (d *BinaryDecoder) readHeader() error {
+	src := d.r
+	if d.compress {
+		var err error
+		if src, err = d.decompress(); err != nil {
+			return err
+		}
+	}
+
+	d.offset = 0
+	d.src = countingReader{src, &d.offset}
+
+	var count uint32
+	if err := binary.Read(d.src, order, &count); err != nil {
+		return err
+	}
+
+	d.remaining = int64(count)
+	return nil
+}
+
+// Reads a u32 length followed by that many units of size bytes.
+// The data is read incrementally, since the length is untrusted.
+func (d *BinaryDecoder) readLengthPrefixed(size int64) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(d.src, order, &length); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	if _, err := io.CopyN(&buf, d.src, int64(length)*size); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeString16(data []byte) string {
+	s := make([]uint16, len(data)/2)
+	for i := range s {
+		s[i] = order.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(s))
+}
+
+// Reads the binary value of the provided type into its textual form.
+func (d *BinaryDecoder) readValue(valueType ValueType) ([]byte, error) {
+	switch valueType {
+	case ValueTypeString:
+		data, err := d.readLengthPrefixed(2)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(decodeString16(data)), nil
+	case ValueTypeUtf8:
+		return d.readLengthPrefixed(1)
+	}
+
+	var size int
+	switch valueType {
+	case ValueTypeBool:
+		size = 1
+	case ValueTypeI32, ValueTypeFloat, ValueTypeU32:
+		size = 4
+	case ValueTypeDouble, ValueTypeU64, ValueTypeI64:
+		size = 8
+	default:
+		if valueType.isUnknown() {
+			// Without a known size, the value extends to the end of the LDF value.
+			return io.ReadAll(d.src)
+		}
+		return nil, fmt.Errorf("cannot decode %v", valueType)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.src, data); err != nil {
+		return nil, err
+	}
+
+	switch valueType {
+	case ValueTypeI32:
+		return strconv.AppendInt(nil, int64(int32(order.Uint32(data))), 10), nil
+	case ValueTypeFloat:
+		return strconv.AppendFloat(nil, float64(math.Float32frombits(order.Uint32(data))), 'g', -1, 32), nil
+	case ValueTypeDouble:
+		return strconv.AppendFloat(nil, math.Float64frombits(order.Uint64(data)), 'g', -1, 64), nil
+	case ValueTypeU32:
+		return strconv.AppendUint(nil, uint64(order.Uint32(data)), 10), nil
+	case ValueTypeBool:
+		if data[0] != 0 {
+			return []byte("1"), nil
+		}
+		return []byte("0"), nil
+	case ValueTypeU64:
+		return strconv.AppendUint(nil, order.Uint64(data), 10), nil
+	default:
+		return strconv.AppendInt(nil, int64(order.Uint64(data)), 10), nil
+	}
+}
+
+func (d *BinaryDecoder) readToken() (Token, error) {
+	d.tokenOffset = d.offset
+	token := Token{}
+
+	var keyLength uint8
+	if err := binary.Read(d.src, order, &keyLength); err != nil {
+		return token, err
+	}
+
+	if keyLength%2 != 0 {
+		return token, fmt.Errorf("invalid key length: %d", keyLength)
+	}
+
+	key := make([]byte, keyLength)
+	if _, err := io.ReadFull(d.src, key); err != nil {
+		return token, err
+	}
+	token.Key = decodeString16(key)
+
+	var valueType uint8
+	if err := binary.Read(d.src, order, &valueType); err != nil {
+		return token, err
+	}
+	token.Type = ValueType(valueType)
+
+	value, err := d.readValue(token.Type)
+	if err != nil {
+		return token, err
+	}
+	token.Value = value
+
+	return token, nil
+}
+
+// Reads the next token of the current value. Binary tokens
+// are converted into the same textual form as [TextDecoder]'s.
+func (d *BinaryDecoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+
+	if d.remaining < 0 {
+		if err := d.readHeader(); err != nil {
+			d.err = fmt.Errorf("invalid header: %v", err)
+			return false
+		}
+	}
+
+	if d.remaining == 0 {
+		return false
+	}
+
+	token, err := d.readToken()
+	if err != nil {
+		d.err = newDecodeError(token.Key, d.position(), fmt.Errorf("invalid token: %w", err))
+		return false
+	}
+
+	d.remaining--
+	if token.Type.isUnknown() {
+		d.remaining = 0
+	}
+	d.token = token
+	return true
+}
+
+// Returns the position of the current token.
+func (d *BinaryDecoder) position() tokenPosition {
+	return tokenPosition{offset: d.tokenOffset}
+}
+
+func (d BinaryDecoder) Token() Token {
+	return d.token
+}
+
+func (d BinaryDecoder) Err() error {
+	return d.err
+}
+
+func (d *BinaryDecoder) All() (seq TokenSeq, finish func() error) {
+	var seqErr error
+	seq = func(yield func(Token) bool) {
+		for d.Next() {
+			if !yield(d.Token()) {
+				return
+			}
+		}
+
+		if d.Err() != nil {
+			seqErr = d.Err()
+		}
+	}
+
+	return seq, func() error { return seqErr }
+}
+
+// Reads the next binary LDF value into v. Tokens not consumed
+// by v are skipped. Errors caused by a specific token are
+// returned as a [*DecodeError].
+func (d *BinaryDecoder) Decode(v any) error {
+	d.remaining = -1
+
+	state := newDecodeState()
+	state.disallowUnknownKeys = d.disallowUnknownKeys
+
+	seq, _ := d.All()
+	if err := decode(state, v, state.track(seq, d.position)); err != nil {
+		return wrapDecodeError(err)
+	}
+
+	for d.Next() {
+	}
+
+	if d.Err() != nil {
+		return wrapDecodeError(d.Err())
+	}
+	return nil
+}
+
+// MarshalBinary returns the binary LDF encoding of v.
+//
+// The encoding starts with the u32 number of keys. Each key is
+// encoded as its u8 length in bytes followed by the UTF-16 key, the
+// u8 [ValueType], and the little-endian value:
+//
+//   - [ValueTypeString] is a u32 length followed by UTF-16 characters.
+//   - [ValueTypeUtf8] is a u32 length followed by bytes.
+//   - [ValueTypeBool] is a single byte.
+//   - All other value types are encoded as their fixed-size value.
+//
+// Values of the undocumented value types cannot be encoded, since
+// their binary sizes are unknown. When decoded, they hold the rest
+// of the encoded value as a [RawValue]. See [BinaryDecoder].
+//
+// Values are encoded from the same struct fields and tags as [MarshalText].
+func MarshalBinary(v any) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := NewBinaryEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary parses the binary LDF encoded data into v.
+// Values are decoded following the same rules as [UnmarshalText].
+func UnmarshalBinary(data []byte, v any) error {
+	return NewBinaryDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,231 @@
+package ldf_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/encoding/ldf"
+)
+
+func checkBinaryRoundTrip[T any](t *testing.T, expected T, compress bool) {
+	buf := bytes.Buffer{}
+
+	encoder := ldf.NewBinaryEncoder(&buf)
+	if compress {
+		encoder.UseCompression()
+	}
+
+	if err := encoder.Encode(expected); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder := ldf.NewBinaryDecoder(&buf)
+	if compress {
+		decoder.UseCompression()
+	}
+
+	var actual T
+	if err := decoder.Decode(&actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("\nexpected = %+v\nactual   = %+v", expected, actual)
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("expected decoder to read the entire value but %d bytes remain", buf.Len())
+	}
+}
+
+func TestBinary(t *testing.T) {
+	t.Run("layout", func(t *testing.T) {
+		data, err := ldf.MarshalBinary([]ldf.Entry{
+			{Key: "ab", Value: int32(-2)},
+			{Key: "s", Value: "hé"},
+			{Key: "b", Value: true},
+			{Key: "u", Value: []byte("xy")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []byte{
+			4, 0, 0, 0,
+			4, 'a', 0, 'b', 0, 1, 0xfe, 0xff, 0xff, 0xff,
+			2, 's', 0, 0, 2, 0, 0, 0, 'h', 0, 0xe9, 0,
+			2, 'b', 0, 7, 1,
+			2, 'u', 0, 13, 2, 0, 0, 0, 'x', 'y',
+		}
+		if !bytes.Equal(expected, data) {
+			t.Errorf("\nexpected = %v\nactual   = %v", expected, data)
+		}
+	})
+
+	basic := Basic{
+		String:  "Save Imagination! :)",
+		Int32:   -2123311855,
+		Float:   0.2394242421,
+		Double:  -15555313.199119,
+		Uint32:  2340432028,
+		Boolean: true,
+	}
+
+	strs := Strings{
+		Std8:  "Crazy? I was crazy once.",
+		Std16: "They put me in a room. A rubber room. A rubber room of rats.",
+		U16:   ldf.ToString16("And the rats made me crazy."),
+		Bytes: []byte("I can't think of any more interesting strings."),
+	}
+
+	for _, compress := range []bool{false, true} {
+		name := "uncompressed"
+		if compress {
+			name = "compressed"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			checkBinaryRoundTrip(t, basic, compress)
+			checkBinaryRoundTrip(t, strs, compress)
+			checkBinaryRoundTrip(t, Integers{Int: -9007199254740993, Uint: 18446744073709551615}, compress)
+			checkBinaryRoundTrip(t, WithEncodings{IntList: Ints{1, 2, 3}}, compress)
+			checkBinaryRoundTrip(t, Embedded{SubStruct{A: 7}, 1.5}, compress)
+			checkBinaryRoundTrip(t, newSpawner(), compress)
+			checkBinaryRoundTrip(t, Marshalers{Faction: FactionParadox, Color: Color{1, 2, 3}, Palette: []Color{{4, 5, 6}}, Position: Vector3{1, 2, 3}}, compress)
+			checkBinaryRoundTrip(t, EmbeddedMap{Name: "Dench", Age: 19, Map: ldf.Map{"extra": int32(4)}}, compress)
+		})
+	}
+
+	t.Run("utf16", func(t *testing.T) {
+		v := Utf16Strings{
+			String16: ldf.ToString16("🧱 bricks"),
+			Uint16s:  ldf.ToString16("𝄞"),
+			String:   "string",
+			Bytes:    []byte("bytes"),
+			Raw16:    ldf.ToString16("raw"),
+		}
+		checkBinaryRoundTrip(t, v, false)
+
+		data, err := ldf.MarshalBinary(ldf.Entry{Key: "𝄞", Value: ldf.ToString16("𝄞")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []byte{1, 0, 0, 0, 4, 0x34, 0xd8, 0x1e, 0xdd, 0, 2, 0, 0, 0, 0x34, 0xd8, 0x1e, 0xdd}
+		if !bytes.Equal(expected, data) {
+			t.Errorf("\nexpected = %v\nactual   = %v", expected, data)
+		}
+	})
+
+	t.Run("value_types", func(t *testing.T) {
+		v := ValueTypes{
+			Object:  1152921504606846994,
+			ID:      1152921504606846995,
+			Small:   42,
+			Float:   1.5,
+			Text:    "text",
+			Unknown: ldf.RawValue{Type: ldf.ValueTypeI32, Value: []byte("-3")},
+		}
+		checkBinaryRoundTrip(t, v, false)
+
+		v.Unknown.Type = ldf.ValueTypeUnknown11
+		if _, err := ldf.MarshalBinary(v); !errors.Is(err, ldf.ErrBinaryUnsupported) || !strings.Contains(err.Error(), "Unknown11") {
+			t.Errorf("expected %v naming Unknown11 but got %v", ldf.ErrBinaryUnsupported, err)
+		}
+
+		// An "a" key with value type 2, followed by the rest of the
+		// value, which is kept as raw bytes since its size is unknown.
+		data := []byte{2, 0, 0, 0, 2, 'a', 0, 2, 1, 2, 3, 4, 2, 'b', 0, 1, 5, 0, 0, 0}
+		actual := ldf.Map{}
+		if err := ldf.UnmarshalBinary(data, actual); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := ldf.Map{
+			"a": ldf.RawValue{Type: ldf.ValueTypeUnknown2, Value: []byte{1, 2, 3, 4, 2, 'b', 0, 1, 5, 0, 0, 0}},
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected %v but got %v", expected, actual)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		expected := ldf.Map{
+			"string": "string",
+			"i32":    int32(-1),
+			"float":  float32(1.25),
+			"double": 3.5,
+			"u32":    uint32(4),
+			"bool":   true,
+			"u64":    uint64(5),
+			"i64":    int64(-6),
+			"utf8":   []byte("utf8"),
+		}
+
+		data, err := ldf.MarshalBinary(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := ldf.Map{}
+		if err := ldf.UnmarshalBinary(data, actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\nexpected = %v\nactual   = %v", expected, actual)
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		buf := bytes.Buffer{}
+		encoder := ldf.NewBinaryEncoder(&buf)
+		encoder.UseCompression()
+
+		for i := range int32(3) {
+			if err := encoder.Encode(ldf.Entry{Key: "i", Value: i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		decoder := ldf.NewBinaryDecoder(&buf)
+		decoder.UseCompression()
+
+		for i := range int32(3) {
+			entry := ldf.Entry{}
+			if err := decoder.Decode(&entry); err != nil {
+				t.Fatal(err)
+			}
+
+			if entry.Value != i {
+				t.Errorf("expected %d but got %v", i, entry.Value)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := ldf.MarshalBinary(ldf.Entry{Key: string(make([]byte, 128)), Value: true}); err == nil {
+			t.Error("expected key too long error")
+		}
+
+		data, err := ldf.MarshalBinary(basic)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ldf.UnmarshalBinary(data[:len(data)-1], &Basic{}); err == nil {
+			t.Error("expected truncated data error")
+		}
+
+		if err := ldf.UnmarshalBinary([]byte{1, 0, 0, 0, 3, 'a', 0, 0}, &ldf.Map{}); err == nil || !strings.Contains(err.Error(), "key length") {
+			t.Errorf("expected invalid key length error but got: %v", err)
+		}
+
+		if err := ldf.UnmarshalBinary([]byte{1, 0, 0, 0, 2, 'a', 0, 1, 1}, &Basic{}); err == nil {
+			t.Error("expected truncated value error")
+		}
+	})
+}
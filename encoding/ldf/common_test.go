@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/I-Am-Dench/goverbuild/encoding/ldf"
 )
@@ -141,3 +142,145 @@ type EmbeddedMap struct {
 	Age  uint   `ldf:"age"`
 	ldf.Map
 }
+
+type ValueTypes struct {
+	Object  ldf.ObjectID `ldf:"object"`
+	ID      uint64       `ldf:"id,type=9"`
+	Small   int          `ldf:"small,type=5"`
+	Float   float64      `ldf:"float,type=3"`
+	Text    string       `ldf:"text,type=13"`
+	Unknown ldf.RawValue `ldf:"unknown"`
+}
+
+type Utf16Strings struct {
+	String16 ldf.String16 `ldf:"string16"`
+	Uint16s  []uint16     `ldf:"uint16s"`
+	String   string       `ldf:"string,utf16"`
+	Bytes    []byte       `ldf:"bytes,utf16"`
+	Raw16    ldf.String16 `ldf:"raw16,raw"`
+}
+
+type PointerMarshaler struct {
+	Value string
+}
+
+func (m *PointerMarshaler) MarshalText() ([]byte, error) {
+	return []byte("<" + m.Value + ">"), nil
+}
+
+func (m *PointerMarshaler) UnmarshalText(text []byte) error {
+	m.Value = strings.TrimSuffix(strings.TrimPrefix(string(text), "<"), ">")
+	return nil
+}
+
+type SpawnerConfig struct {
+	Name         string `ldf:"name"`
+	MaxPerNode   *int32 `ldf:"max_per_node"`
+	ActiveOnLoad bool   `ldf:"active_on_load"`
+}
+
+type Point struct {
+	X float32 `ldf:"x"`
+	Y float32 `ldf:"y"`
+}
+
+type Spawner struct {
+	*SubStruct
+	Spawner  SpawnerConfig `ldf:"spawner_"`
+	Position struct {
+		X float32 `ldf:"pos_x"`
+		Y float32 `ldf:"pos_y"`
+	}
+	Templates []int32          `ldf:"spawntemplate"`
+	Node      *Point           `ldf:"node_"`
+	Script    *string          `ldf:"script"`
+	Extra     any              `ldf:"extra"`
+	Marshaler PointerMarshaler `ldf:"marshaler"`
+}
+
+func newSpawner() Spawner {
+	maxPerNode := int32(2)
+	script := "scripts\\ai\\L_SPAWNER.lua"
+
+	v := Spawner{
+		SubStruct: &SubStruct{A: 3},
+		Spawner:   SpawnerConfig{Name: "spawner", MaxPerNode: &maxPerNode, ActiveOnLoad: true},
+		Templates: []int32{10, 11},
+		Script:    &script,
+		Extra:     int32(5),
+		Marshaler: PointerMarshaler{"m"},
+	}
+	v.Position.X = 1
+	v.Position.Y = -2
+
+	return v
+}
+
+type Faction int
+
+const (
+	FactionNone Faction = iota
+	FactionSentinel
+	FactionParadox
+)
+
+func (f Faction) MarshalLDF() (ldf.ValueType, []byte, error) {
+	return ldf.ValueTypeI32, strconv.AppendInt(nil, int64(f), 10), nil
+}
+
+func (f *Faction) UnmarshalLDF(valueType ldf.ValueType, value []byte) error {
+	switch valueType {
+	case ldf.ValueTypeI32, ldf.ValueTypeU32, ldf.ValueTypeI64, ldf.ValueTypeU64:
+	default:
+		return fmt.Errorf("cannot decode %v into Faction", valueType)
+	}
+
+	v, err := strconv.ParseInt(string(value), 10, 32)
+	if err != nil {
+		return err
+	}
+
+	*f = Faction(v)
+	return nil
+}
+
+// Packed into a single u32 value.
+type Color struct {
+	R, G, B uint8
+}
+
+func (c *Color) MarshalLDF() (ldf.ValueType, []byte, error) {
+	return ldf.ValueTypeU32, strconv.AppendUint(nil, uint64(c.R)<<16|uint64(c.G)<<8|uint64(c.B), 10), nil
+}
+
+func (c *Color) UnmarshalLDF(valueType ldf.ValueType, value []byte) error {
+	if valueType != ldf.ValueTypeU32 {
+		return fmt.Errorf("cannot decode %v into Color", valueType)
+	}
+
+	v, err := strconv.ParseUint(string(value), 10, 24)
+	if err != nil {
+		return err
+	}
+
+	c.R, c.G, c.B = uint8(v>>16), uint8(v>>8), uint8(v)
+	return nil
+}
+
+// A [ldf.Marshaler] produces a single value, so types spread over
+// several keys are nested structs instead.
+type Vector3 struct {
+	X float32 `ldf:"x"`
+	Y float32 `ldf:"y"`
+	Z float32 `ldf:"z"`
+}
+
+type Marshalers struct {
+	Faction   Faction  `ldf:"faction"`
+	Color     Color    `ldf:"color"`
+	Highlight *Color   `ldf:"highlight"`
+	Palette   []Color  `ldf:"palette"`
+	Position  Vector3  `ldf:"position_"`
+	Rotation  *Vector3 `ldf:"rotation_"`
+	ID        Faction  `ldf:"id,type=9"`
+}
@@ -3,12 +3,14 @@ package ldf
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"unicode/utf16"
 )
@@ -66,6 +68,9 @@ func (t Token) Interface() (any, error) {
 	case ValueTypeUtf8:
 		return t.Value, nil
 	default:
+		if t.Type.isUnknown() {
+			return RawValue{t.Type, bytes.Clone(t.Value)}, nil
+		}
 		return nil, fmt.Errorf("cannot decode %v", t.Type)
 	}
 }
@@ -115,7 +120,10 @@ func (t Token) Entry() (entry Entry, err error) {
 	case ValueTypeUtf8:
 		entry.Value = t.Value
 	default:
-		return entry, fmt.Errorf("cannot decode %v", t.Type)
+		if !t.Type.isUnknown() {
+			return entry, fmt.Errorf("cannot decode %v", t.Type)
+		}
+		entry.Value = RawValue{t.Type, bytes.Clone(t.Value)}
 	}
 
 	entry.Key = t.Key
@@ -124,17 +132,90 @@ func (t Token) Entry() (entry Entry, err error) {
 
 type TokenSeq = iter.Seq[Token]
 
+// Unmarshaler is the interface implemented by types that can
+// decode a single LDF value of themselves. The value must be
+// copied if it is retained after returning. Types spread over
+// several keys should be nested structs instead.
+type Unmarshaler interface {
+	UnmarshalLDF(valueType ValueType, value []byte) error
+}
+
+// State shared by [TextDecoder] and [BinaryDecoder]
+// while unmarshaling a [TokenSeq].
+type decodeState struct {
+	// quick hack for now
+	tokensDecoded map[string]struct{}
+
+	// When lax is true, errors are collected
+	// in errs instead of being returned.
+	lax  bool
+	errs []*DecodeError
+
+	disallowUnknownKeys bool
+
+	// The position of the last token read with each key,
+	// which is the token decoded into a struct or map.
+	positions map[string]tokenPosition
+}
+
+func newDecodeState() *decodeState {
+	return &decodeState{
+		tokensDecoded: make(map[string]struct{}),
+		positions:     make(map[string]tokenPosition),
+	}
+}
+
+// Records the position of each token of seq, as
+// reported by pos when the token is yielded.
+func (s *decodeState) track(seq TokenSeq, pos func() tokenPosition) TokenSeq {
+	return func(yield func(Token) bool) {
+		for token := range seq {
+			s.positions[token.Key] = pos()
+			if !yield(token) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a [*DecodeError] for the token, or
+// nil if the error was collected in lax mode.
+func (s *decodeState) fail(token Token, err error) error {
+	decodeErr := newDecodeError(token.Key, s.positions[token.Key], err)
+	if s.lax {
+		s.errs = append(s.errs, decodeErr)
+		return nil
+	}
+	return decodeErr
+}
+
 type TextDecoder struct {
 	delim *regexp.Regexp
 	s     *bufio.Scanner
 	err   error
 
 	// When Lax is true, the decoder will attempt will attempt
-	// to unmarshal as much as it can, ignoring errors.
-	lax bool
+	// to unmarshal as much as it can, collecting errors.
+	lax  bool
+	errs []*DecodeError
 
-	// quick hack for now
-	tokensDecoded map[string]struct{}
+	disallowUnknownKeys bool
+
+	// The position of the next and current tokens.
+	offset, tokenOffset int64
+	line, tokenLine     int
+
+	// When escape is true, escaped delimiters are not split on
+	// and tokens are unescaped. See [TextDecoder.UseEscapes].
+	escape bool
+
+	// When document is true, the original text of each token is
+	// recorded in text. delimText is the delimiter which ended the last
+	// scanned token, and sep is the text skipped since the last token.
+	document  bool
+	delimText []byte
+	sep       []byte
+	text      tokenText
 
 	token Token
 }
@@ -152,77 +233,177 @@ func (d *TextDecoder) SetDelim(delim *regexp.Regexp) {
 	d.delim = delim
 }
 
+// Skips tokens and values which cannot be decoded. The
+// errors are collected and returned by [TextDecoder.Errors].
 func (d *TextDecoder) UseLax() {
 	d.lax = true
 }
 
+// Returns the errors collected in lax mode since
+// the last call to [TextDecoder.Reset], in order.
+func (d TextDecoder) Errors() []*DecodeError {
+	return d.errs
+}
+
+// Causes Decode to return an error when decoding into a struct
+// which has no field for a key. Keys captured by an embedded
+// map are not unknown.
+func (d *TextDecoder) DisallowUnknownKeys() {
+	d.disallowUnknownKeys = true
+}
+
+// Unescapes keys and values escaped by a [TextEncoder] which
+// called [TextEncoder.UseEscapes]. See [Escape].
+func (d *TextDecoder) UseEscapes() {
+	d.escape = true
+}
+
+// Returns the index of the first delimiter within data
+// which is not escaped, or nil if there is none.
+func (d *TextDecoder) findDelim(data []byte) []int {
+	for offset := 0; offset < len(data); {
+		match := d.delim.FindIndex(data[offset:])
+		if match == nil {
+			return nil
+		}
+
+		match[0] += offset
+		match[1] += offset
+		if !d.escape || !isEscaped(data, match[0]) {
+			return match
+		}
+
+		offset = match[0] + 1
+	}
+	return nil
+}
+
 func (d *TextDecoder) splitDelim(data []byte, atEOF bool) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
 		return 0, nil, nil
 	}
 
-	match := d.delim.FindIndex(data)
+	match := d.findDelim(data)
 	if match != nil {
+		d.advance(data[:match[1]])
+		d.delimText = append(d.delimText[:0], data[match[0]:match[1]]...)
 		return match[1], data[:match[0]], nil
 	}
 
 	if atEOF {
+		d.advance(data)
+		d.delimText = d.delimText[:0]
 		return len(data), data, nil
 	}
 
 	return 0, nil, nil
 }
 
+// Returns the position of the current token.
+func (d *TextDecoder) position() tokenPosition {
+	return tokenPosition{d.tokenOffset, d.tokenLine}
+}
+
+// Records the position of the token at the start of consumed.
+func (d *TextDecoder) advance(consumed []byte) {
+	d.tokenOffset, d.tokenLine = d.offset, d.line
+
+	// Report the line of the token's first non-whitespace character.
+	trimmed := bytes.TrimLeft(consumed, " \t\r\n")
+	d.tokenLine += bytes.Count(consumed[:len(consumed)-len(trimmed)], []byte("\n"))
+
+	d.offset += int64(len(consumed))
+	d.line += bytes.Count(consumed, []byte("\n"))
+}
+
 func (d TextDecoder) decodeToken(rawToken []byte) (Token, error) {
 	key, valueWithType, ok := bytes.Cut(rawToken, []byte("="))
+	if d.escape {
+		// Escaped keys may contain "=".
+		i := indexUnescaped(rawToken, '=')
+		if ok = i >= 0; ok {
+			key, valueWithType = rawToken[:i], rawToken[i+1:]
+		}
+	}
+
+	token := Token{}
 	if !ok {
-		return Token{}, fmt.Errorf("missing key-value pair: %s", rawToken)
+		return token, fmt.Errorf("missing key-value pair: %s", rawToken)
+	}
+
+	// Keys are trimmed before unescaping, so escaped whitespace is kept.
+	key = bytes.TrimSpace(key)
+	if d.escape {
+		key = Unescape(key)
 	}
+	token.Key = string(key)
 
 	rawValueType, value, ok := bytes.Cut(valueWithType, []byte(":"))
 	if !ok {
-		return Token{}, fmt.Errorf("missing value type: %s", rawToken)
+		return token, fmt.Errorf("missing value type: %s", rawToken)
 	}
 
 	valueType, err := strconv.ParseInt(string(bytes.TrimSpace(rawValueType)), 10, 8)
 	if err != nil {
-		return Token{}, fmt.Errorf("invalid value type: %s: %v", rawToken, err)
+		return token, fmt.Errorf("invalid value type: %s: %v", rawToken, err)
 	}
 
 	if valueType < 0 || valueType > int64(ValueTypeUtf8) {
-		return Token{}, fmt.Errorf("invalid value type: %s: %d", rawToken, valueType)
+		return token, fmt.Errorf("invalid value type: %s: %d", rawToken, valueType)
 	}
 
-	return Token{
-		Key:   string(bytes.TrimSpace(key)),
-		Type:  ValueType(valueType),
-		Value: value,
-	}, nil
+	if d.escape {
+		value = Unescape(value)
+	}
+
+	token.Type = ValueType(valueType)
+	token.Value = value
+	return token, nil
 }
 
 func (d *TextDecoder) Reset(r io.Reader) {
 	d.s = bufio.NewScanner(r)
 	d.s.Split(d.splitDelim)
 
-	d.tokensDecoded = make(map[string]struct{})
+	d.err = nil
+	d.errs = nil
+	d.offset, d.line = 0, 1
+	d.sep = nil
+}
+
+// Records text which is not part of a token as a separator.
+func (d *TextDecoder) skip(text []byte) {
+	if d.document {
+		d.sep = append(d.sep, text...)
+		d.sep = append(d.sep, d.delimText...)
+	}
 }
 
 func (d *TextDecoder) Next() bool {
 	for d.s.Scan() {
 		rawToken := d.s.Bytes()
 		if len(bytes.TrimSpace(rawToken)) == 0 {
+			d.skip(rawToken)
 			continue
 		}
 
 		token, err := d.decodeToken(rawToken)
 		if err != nil {
+			decodeErr := newDecodeError(token.Key, d.position(), fmt.Errorf("invalid token: %w", err))
 			if d.lax {
+				d.errs = append(d.errs, decodeErr)
+				d.skip(rawToken)
 				continue
 			}
-			d.err = fmt.Errorf("invalid token: %v", err)
+			d.err = decodeErr
 			return false
 		}
 
+		if d.document {
+			d.text = tokenText{bytes.Clone(rawToken), d.sep}
+			d.sep = bytes.Clone(d.delimText)
+		}
+
 		d.token = token
 		return true
 	}
@@ -259,18 +440,33 @@ func (d *TextDecoder) All() (seq TokenSeq, finish func() error) {
 	return seq, func() error { return seqErr }
 }
 
-func (d *TextDecoder) decodeMapAny(m Map, seq TokenSeq) error {
+func decodeMapAny(state *decodeState, m Map, seq TokenSeq) error {
 	for token := range seq {
 		v, err := token.Interface()
 		if err != nil {
-			return err
+			if err := state.fail(token, err); err != nil {
+				return err
+			}
+			continue
 		}
 		m[token.Key] = v
 	}
-	return d.Err()
+	return nil
 }
 
-func (d *TextDecoder) decodeValue(token Token, rtype reflect.Type) (reflect.Value, error) {
+func decodeValue(token Token, rtype reflect.Type) (reflect.Value, error) {
+	if reflect.PointerTo(rtype).Implements(unmarshalerType) {
+		ptr := reflect.New(rtype)
+		if err := ptr.Interface().(Unmarshaler).UnmarshalLDF(token.Type, token.Value); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr.Elem(), nil
+	}
+
+	if rtype == rawValueType {
+		return reflect.ValueOf(RawValue{token.Type, bytes.Clone(token.Value)}), nil
+	}
+
 	switch token.Type {
 	case ValueTypeString:
 		if rtype.Kind() == reflect.Slice {
@@ -322,6 +518,9 @@ func (d *TextDecoder) decodeValue(token Token, rtype reflect.Type) (reflect.Valu
 	case ValueTypeUtf8:
 		return reflect.ValueOf(token.Value), nil
 	default:
+		if token.Type.isUnknown() {
+			return reflect.ValueOf(RawValue{token.Type, bytes.Clone(token.Value)}), nil
+		}
 		return reflect.Value{}, fmt.Errorf("unhandled value type: %v", token.Type)
 	}
 }
@@ -335,7 +534,19 @@ func first(seq TokenSeq) (token Token, ok bool) {
 	return token, ok
 }
 
-func (d *TextDecoder) decode(v any, seq TokenSeq) error {
+// Reports an error for each token not decoded into a struct field or map.
+func checkUnknownKeys(state *decodeState, tokens []Token) error {
+	for _, token := range tokens {
+		if _, ok := state.tokensDecoded[token.Key]; !ok {
+			if err := state.fail(token, errors.New("unknown key")); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decode(state *decodeState, v any, seq TokenSeq) error {
 	if v == nil {
 		return errors.New("cannot decode nil")
 	}
@@ -345,7 +556,7 @@ func (d *TextDecoder) decode(v any, seq TokenSeq) error {
 		if token, ok := first(seq); ok {
 			kv, err := token.Entry()
 			if err != nil {
-				return err
+				return state.fail(token, err)
 			}
 			*val = kv
 		}
@@ -355,7 +566,10 @@ func (d *TextDecoder) decode(v any, seq TokenSeq) error {
 		for token := range seq {
 			kv, err := token.Entry()
 			if err != nil {
-				return err
+				if err := state.fail(token, err); err != nil {
+					return err
+				}
+				continue
 			}
 			values = append(values, kv)
 		}
@@ -363,7 +577,13 @@ func (d *TextDecoder) decode(v any, seq TokenSeq) error {
 
 		return nil
 	case Map:
-		return d.decodeMapAny(val, seq)
+		return decodeMapAny(state, val, seq)
+	case *Document:
+		for token := range seq {
+			token.Value = bytes.Clone(token.Value)
+			val.Tokens = append(val.Tokens, token)
+		}
+		return nil
 	}
 
 	value := reflect.ValueOf(v)
@@ -374,26 +594,62 @@ func (d *TextDecoder) decode(v any, seq TokenSeq) error {
 	value = reflect.Indirect(value)
 	switch value.Kind() {
 	case reflect.Struct, reflect.Map:
-		unmarshal := getArshaler(value.Type()).textUnmarshal
+		tokens := []Token{}
+		seen := func(yield func(Token) bool) {
+			for token := range seq {
+				tokens = append(tokens, token)
+				if !yield(token) {
+					return
+				}
+			}
+		}
 
-		seq, finish := d.All()
-		if err := unmarshal(d, value, seq); err != nil {
+		unmarshal := getArshaler(value.Type()).unmarshal
+		if err := unmarshal(state, value, seen); err != nil {
 			return err
 		}
-		return finish()
+
+		if state.disallowUnknownKeys && value.Kind() == reflect.Struct {
+			return checkUnknownKeys(state, tokens)
+		}
+		return nil
 	default:
 		return fmt.Errorf("cannot decode %v", value.Type())
 	}
 }
 
+func (d *TextDecoder) newDecodeState() *decodeState {
+	state := newDecodeState()
+	state.lax = d.lax
+	state.disallowUnknownKeys = d.disallowUnknownKeys
+	return state
+}
+
+// Merges the errors collected by state, ordered by their offsets.
+func (d *TextDecoder) collectErrors(state *decodeState) {
+	d.errs = append(d.errs, state.errs...)
+	slices.SortStableFunc(d.errs, func(a, b *DecodeError) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+}
+
+// Decodes the remaining tokens into v. Errors caused by a specific
+// token are returned as a [*DecodeError].
 func (d *TextDecoder) Decode(v any) error {
+	if doc, ok := v.(*Document); ok {
+		return d.decodeDocument(doc)
+	}
+
+	state := d.newDecodeState()
+	defer d.collectErrors(state)
+
 	seq, finish := d.All()
-	if err := d.decode(v, seq); err != nil {
-		return fmt.Errorf("ldf: decode: %v", err)
+	if err := decode(state, v, state.track(seq, d.position)); err != nil {
+		return wrapDecodeError(err)
 	}
 
 	if err := finish(); err != nil {
-		return fmt.Errorf("ldf: decode: %v", err)
+		return wrapDecodeError(err)
 	}
 	return nil
 }
@@ -404,15 +660,27 @@ func (d *TextDecoder) Decode(v any) error {
 //
 // Fields, by default, are delimited by a comma, a newline
 // character, or a CRLF. The delimiter can be changed on a custom
-// decoder by calling [TextDecoder.SetDelim].
+// decoder by calling [TextDecoder.SetDelim]. Escaped delimiters
+// are only supported by decoders which call [TextDecoder.UseEscapes].
 //
 // UnmarshalText returns an error if the encoded value type does
 // not match the struct field's type.
 //
-// If the field type is a slice, a new slice is created, except
-// when the slice implements [encoding.TextUnmarshaler].
+// Integers can be decoded into any integer field if the value
+// fits. Both [ValueTypeString] and [ValueTypeUtf8] can be decoded
+// into strings, []bytes, [String16]s, and []uint16s. Any value can
+// be decoded into a [RawValue] or an empty interface field.
 //
-// Fields that implement the [encoding.TextUnmarshaler] interface are
+// If the field type is a slice, a new slice is created from the
+// indexed keys, stopping at the first missing index, except when
+// the slice implements [encoding.TextUnmarshaler].
+//
+// Pointer fields are allocated when their key is present. Pointers
+// to nested structs are only allocated if any of their keys are present.
+//
+// Fields that implement the [Unmarshaler] interface are given every
+// value, regardless of its value type. Otherwise, fields that
+// implement the [encoding.TextUnmarshaler] interface are
 // only unmarshaled if the value type is either [ValueTypeString] or
 // [ValueTypeUtf8]. If the field's type is not a pointer, the pointer
 // type to that field is checked for compatibility with [encoding.TextUnmarshaler].
@@ -422,7 +690,12 @@ func (d *TextDecoder) Decode(v any) error {
 //     type is a string, it is instead decoded as a string.
 //   - [ValueTypeString] is decoded as a string. If the map's value
 //     type is a [String16] or []uint16, it is decoded as a []uint16.
+//   - Map values implementing [Unmarshaler] are decoded with it.
 //   - [encoding.TextUnmarshaler] is not supported.
+//   - Undocumented value types are decoded as [RawValue]s.
+//
+// To keep the order and original text of every token, decode
+// into a [Document].
 func UnmarshalText(data []byte, v any) error {
 	buf := bytes.NewBuffer(data)
 	return NewTextDecoder(buf).Decode(v)
@@ -2,6 +2,7 @@ package ldf_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -304,6 +305,236 @@ func TestDecode(t *testing.T) {
 		}
 	})
 
+	t.Run("nested", func(t *testing.T) {
+		expected := newSpawner()
+
+		data, err := ldf.MarshalText(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := Spawner{}
+		if err := ldf.UnmarshalText(data, &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\nexpected = %+v\nactual   = %+v", expected, actual)
+		}
+
+		if actual.Node != nil {
+			t.Errorf("expected nil node but got %+v", actual.Node)
+		}
+
+		// Indices stop at the first missing key.
+		actual = Spawner{}
+		if err := ldf.UnmarshalText([]byte("spawntemplate0=1:1,spawntemplate1=1:2,spawntemplate3=1:4,node_y=3:5"), &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(actual.Templates, []int32{1, 2}) {
+			t.Errorf("expected templates [1 2] but got %v", actual.Templates)
+		}
+
+		if actual.Node == nil || actual.Node.Y != 5 {
+			t.Errorf("expected node y 5 but got %+v", actual.Node)
+		}
+	})
+
+	t.Run("utf16", func(t *testing.T) {
+		data := []byte("string16=13:🧱 bricks,uint16s=0:𝄞,string=0:string,bytes=0:bytes,raw16=13:raw")
+
+		expected := Utf16Strings{
+			String16: ldf.ToString16("🧱 bricks"),
+			Uint16s:  []uint16{0xd834, 0xdd1e},
+			String:   "string",
+			Bytes:    []byte("bytes"),
+			Raw16:    ldf.ToString16("raw"),
+		}
+
+		actual := Utf16Strings{}
+		if err := ldf.UnmarshalText(data, &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\nexpected = %+v\nactual   = %+v", expected, actual)
+		}
+	})
+
+	t.Run("value_types", func(t *testing.T) {
+		data := []byte("object=9:1152921504606846994,id=9:1152921504606846995,small=5:42,float=3:1.5,text=13:text,unknown=10:a:b")
+
+		expected := ValueTypes{
+			Object:  1152921504606846994,
+			ID:      1152921504606846995,
+			Small:   42,
+			Float:   1.5,
+			Text:    "text",
+			Unknown: ldf.RawValue{Type: ldf.ValueTypeUnknown10, Value: []byte("a:b")},
+		}
+
+		actual := ValueTypes{}
+		if err := ldf.UnmarshalText(data, &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\nexpected = %+v\nactual   = %+v", expected, actual)
+		}
+
+		if err := ldf.UnmarshalText([]byte("id=9:-1"), &actual); err == nil {
+			t.Error("expected negative object ID to overflow uint64 field")
+		}
+
+		m := ldf.Map{}
+		if err := ldf.UnmarshalText([]byte("a=2:two,b=6:,c=12:12"), m); err != nil {
+			t.Fatal(err)
+		}
+
+		expectedMap := ldf.Map{
+			"a": ldf.RawValue{Type: ldf.ValueTypeUnknown2, Value: []byte("two")},
+			"b": ldf.RawValue{Type: ldf.ValueTypeUnknown6, Value: []byte{}},
+			"c": ldf.RawValue{Type: ldf.ValueTypeUnknown12, Value: []byte("12")},
+		}
+		if !reflect.DeepEqual(expectedMap, m) {
+			t.Errorf("\nexpected = %v\nactual   = %v", expectedMap, m)
+		}
+
+		objects := map[string]ldf.ObjectID{}
+		if err := ldf.UnmarshalText([]byte("a=9:1,b=9:2"), &objects); err != nil {
+			t.Fatal(err)
+		}
+
+		if objects["a"] != 1 || objects["b"] != 2 {
+			t.Errorf("unexpected object IDs: %v", objects)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		type Fields struct {
+			A int32  `ldf:"A"`
+			B int32  `ldf:"B"`
+			D uint32 `ldf:"D"`
+			E string `ldf:"E"`
+		}
+
+		data := []byte("A=1:1,\nB=1:x\nC\nD=5:-1\n  E=0:ok")
+
+		err := ldf.UnmarshalText(data, &Fields{})
+
+		var decodeErr *ldf.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("expected *DecodeError but got: %v", err)
+		}
+
+		if decodeErr.Key != "B" || decodeErr.Line != 2 || decodeErr.Offset != 7 {
+			t.Errorf("expected error for B at line 2, offset 7 but got: %v", decodeErr)
+		}
+
+		decoder := ldf.NewTextDecoder(bytes.NewReader(data))
+		decoder.UseLax()
+
+		actual := Fields{}
+		if err := decoder.Decode(&actual); err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.A != 1 || actual.E != "ok" {
+			t.Errorf("expected A and E to be decoded but got %+v", actual)
+		}
+
+		errs := decoder.Errors()
+		if len(errs) != 3 {
+			t.Fatalf("expected 3 errors but got %d: %v", len(errs), errs)
+		}
+
+		expected := []struct {
+			key  string
+			line int
+		}{{"B", 2}, {"", 3}, {"D", 4}}
+		for i, e := range expected {
+			if errs[i].Key != e.key || errs[i].Line != e.line {
+				t.Errorf("expected error for %q at line %d but got: %v", e.key, e.line, errs[i])
+			}
+		}
+
+		// The last token with a key is the one decoded.
+		err = ldf.UnmarshalText([]byte("B=1:1\nB=1:x"), &Fields{})
+		if !errors.As(err, &decodeErr) || decodeErr.Key != "B" || decodeErr.Line != 2 {
+			t.Errorf("expected error for the second B at line 2 but got: %v", err)
+		}
+
+		err = ldf.UnmarshalText([]byte("spawner_name=0:a,spawner_max_per_node=1:x"), &Spawner{})
+		if !errors.As(err, &decodeErr) || decodeErr.Key != "spawner_max_per_node" {
+			t.Errorf("expected error for spawner_max_per_node but got: %v", err)
+		}
+
+		err = ldf.UnmarshalBinary([]byte{1, 0, 0, 0, 2, 'a', 0, 1, 1}, &ldf.Map{})
+		if !errors.As(err, &decodeErr) || decodeErr.Key != "a" || decodeErr.Offset != 4 || decodeErr.Line != 0 {
+			t.Errorf("expected error for a at offset 4 but got: %v", err)
+		}
+	})
+
+	t.Run("unknown_keys", func(t *testing.T) {
+		data := []byte("name=0:Alice,age=8:32,occupation=0:Sales")
+
+		decoder := ldf.NewTextDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownKeys()
+
+		err := decoder.Decode(&struct {
+			Name string `ldf:"name"`
+			Age  uint   `ldf:"age"`
+		}{})
+
+		var decodeErr *ldf.DecodeError
+		if !errors.As(err, &decodeErr) || decodeErr.Key != "occupation" {
+			t.Errorf("expected unknown key occupation but got: %v", err)
+		}
+
+		decoder = ldf.NewTextDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownKeys()
+
+		if err := decoder.Decode(&EmbeddedMap{}); err != nil {
+			t.Errorf("expected embedded map to capture unknown keys but got: %v", err)
+		}
+	})
+
+	t.Run("marshaler", func(t *testing.T) {
+		data := []byte("faction=1:2,color=5:16744448,highlight=5:255,palette0=5:66051,position_x=3:1,position_y=3:-2.5,position_z=3:3,rotation_x=3:0,rotation_y=3:1,rotation_z=3:0,id=9:1")
+
+		actual := Marshalers{}
+		if err := ldf.UnmarshalText(data, &actual); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := Marshalers{
+			Faction:   FactionParadox,
+			Color:     Color{255, 128, 0},
+			Highlight: &Color{0, 0, 255},
+			Palette:   []Color{{1, 2, 3}},
+			Position:  Vector3{1, -2.5, 3},
+			Rotation:  &Vector3{0, 1, 0},
+			ID:        FactionSentinel,
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("\nexpected = %+v\nactual   = %+v", expected, actual)
+		}
+
+		if err := ldf.UnmarshalText([]byte("faction=0:2"), &actual); err == nil {
+			t.Error("expected unmarshaler error")
+		}
+
+		factions := map[string]Faction{}
+		if err := ldf.UnmarshalText([]byte("a=1:1,b=1:2"), &factions); err != nil {
+			t.Fatal(err)
+		}
+
+		if factions["a"] != FactionSentinel || factions["b"] != FactionParadox {
+			t.Errorf("unexpected factions: %v", factions)
+		}
+	})
+
 	t.Run("lax", func(t *testing.T) {
 		expected := []ldf.Entry{
 			{"BAR", uint32(42)},
@@ -0,0 +1,212 @@
+package ldf
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+)
+
+// A Document is an ordered list of tokens which, unlike a [Map],
+// keeps the order, duplicates, and value types of its keys.
+//
+// When a Document is decoded by a [TextDecoder], the original text
+// of each token and the delimiters between them are kept. Encoding
+// the Document with a [TextEncoder] writes unmodified tokens exactly
+// as they were read, so editing a single key only changes that key.
+// The original text is kept by index, so tokens should be removed
+// with [Document.Delete] rather than from Tokens directly.
+type Document struct {
+	Tokens []Token
+
+	// The delimiter written before tokens added by [Document.Set]. If
+	// empty, the delimiter before the last decoded token is used, or
+	// a comma if there is none.
+	Delim string
+
+	// The original text of each decoded token, by index.
+	text []tokenText
+
+	// The text before the first token and after the last token.
+	head, trailer []byte
+
+	// Whether the document was decoded with escapes.
+	escape bool
+}
+
+// The original text of a token and the text
+// between it and the previous token.
+type tokenText struct {
+	raw, sep []byte
+}
+
+// Returns the original text of the token at index i, which
+// is empty if the token was not decoded from text.
+func (doc *Document) textAt(i int) tokenText {
+	if i < len(doc.text) {
+		return doc.text[i]
+	}
+	return tokenText{}
+}
+
+// Returns the index of the last token with the key, or -1
+// if there is none. The last token is the one which takes
+// effect when decoding the document into a struct or [Map].
+func (doc *Document) Index(key string) int {
+	for i := len(doc.Tokens) - 1; i >= 0; i-- {
+		if doc.Tokens[i].Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the last token with the key.
+func (doc *Document) Get(key string) (Token, bool) {
+	if i := doc.Index(key); i >= 0 {
+		return doc.Tokens[i], true
+	}
+	return Token{}, false
+}
+
+// Sets the value of the last token with the key, or appends
+// a new token if there is none. The value is encoded following
+// the rules of [MarshalText]. Use a [RawValue] to set the value
+// type explicitly.
+func (doc *Document) Set(key string, value any) error {
+	valueType, text, err := encodeAny(value)
+	if err != nil {
+		return fmt.Errorf("ldf: document: %s: %v", key, err)
+	}
+
+	if i := doc.Index(key); i >= 0 {
+		doc.Tokens[i].Type = valueType
+		doc.Tokens[i].Value = []byte(text)
+		return nil
+	}
+
+	doc.Tokens = append(doc.Tokens, Token{Key: key, Type: valueType, Value: []byte(text)})
+	return nil
+}
+
+// Removes every token with the key. Delete reports
+// whether any tokens were removed.
+func (doc *Document) Delete(key string) bool {
+	n := len(doc.Tokens)
+
+	tokens, text := doc.Tokens[:0], doc.text[:0]
+	for i, token := range doc.Tokens {
+		if token.Key == key {
+			continue
+		}
+
+		tokens = append(tokens, token)
+		if i < len(doc.text) {
+			text = append(text, doc.text[i])
+		}
+	}
+	doc.Tokens, doc.text = tokens, text
+
+	return len(doc.Tokens) != n
+}
+
+func (doc *Document) delim() string {
+	if len(doc.Delim) > 0 {
+		return doc.Delim
+	}
+
+	for i := len(doc.Tokens) - 1; i >= 0; i-- {
+		if sep := doc.textAt(i).sep; sep != nil {
+			return string(sep)
+		}
+	}
+	return ","
+}
+
+// Reports whether the token still matches its original text.
+func (doc *Document) unchanged(token Token, text tokenText) bool {
+	if text.raw == nil {
+		return false
+	}
+
+	original, err := TextDecoder{escape: doc.escape}.decodeToken(text.raw)
+	if err != nil {
+		return false
+	}
+
+	return original.Key == token.Key && original.Type == token.Type && bytes.Equal(original.Value, token.Value)
+}
+
+// Appends the textual encoding of the document. Modified tokens keep
+// the whitespace surrounding their original text.
+func (doc *Document) appendText(buf []byte, escape bool) ([]byte, error) {
+	escape = escape || doc.escape
+	delim := doc.delim()
+
+	buf = append(buf, doc.head...)
+	for i, token := range doc.Tokens {
+		text := doc.textAt(i)
+		if i > 0 {
+			if text.sep != nil {
+				buf = append(buf, text.sep...)
+			} else {
+				buf = append(buf, delim...)
+			}
+		}
+
+		if doc.unchanged(token, text) {
+			buf = append(buf, text.raw...)
+			continue
+		}
+
+		leading := len(text.raw) - len(bytes.TrimLeftFunc(text.raw, unicode.IsSpace))
+		trailing := len(bytes.TrimRightFunc(text.raw, unicode.IsSpace))
+		if leading > trailing {
+			leading, trailing = 0, len(text.raw)
+		}
+
+		buf = append(buf, text.raw[:leading]...)
+		var err error
+		buf, err = appendToken(buf, token.Key, token.Type, string(token.Value), delim, escape)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, text.raw[trailing:]...)
+	}
+	return append(buf, doc.trailer...), nil
+}
+
+// Decodes the remaining tokens into doc, keeping their original text.
+func (d *TextDecoder) decodeDocument(doc *Document) error {
+	d.document = true
+	defer func() {
+		d.document = false
+	}()
+
+	n := len(doc.Tokens)
+	doc.text = doc.text[:min(len(doc.text), n)]
+	for len(doc.text) < n {
+		doc.text = append(doc.text, tokenText{})
+	}
+
+	seq, finish := d.All()
+	for token := range seq {
+		token.Value = bytes.Clone(token.Value)
+		doc.Tokens = append(doc.Tokens, token)
+		doc.text = append(doc.text, d.text)
+	}
+
+	if err := finish(); err != nil {
+		return wrapDecodeError(err)
+	}
+
+	if len(doc.Tokens) > n {
+		if n == 0 {
+			doc.head = doc.text[0].sep
+		}
+		doc.text[n].sep = nil
+	}
+
+	doc.trailer = d.sep
+	doc.escape = doc.escape || d.escape
+	return nil
+}
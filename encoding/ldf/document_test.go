@@ -0,0 +1,185 @@
+package ldf_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/encoding/ldf"
+)
+
+const bootConfig = "SERVERNAME=0:Overbuild Universe (US),\r\n" +
+	"PATCHSERVERIP=0:localhost,\r\n" +
+	"  AUTHSERVERIP = 0:localhost ,\r\n" +
+	"\r\n" +
+	"LOGLEVEL=1:1,\r\n" +
+	"UNKNOWN=11:?,\r\n" +
+	"LOGLEVEL=1:2,\r\n" +
+	"PATCHSERVERPORT=1:80\r\n"
+
+func decodeDocument(t *testing.T, data string) *ldf.Document {
+	doc := &ldf.Document{}
+	if err := ldf.UnmarshalText([]byte(data), doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func checkDocument(t *testing.T, doc *ldf.Document, expected string) {
+	data, err := ldf.MarshalText(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkExpected(t, []byte(expected), data)
+}
+
+func TestDocument(t *testing.T) {
+	t.Run("round_trip", func(t *testing.T) {
+		inputs := []string{
+			bootConfig,
+			"",
+			"\n\n",
+			"a=0:1,b=1:2",
+			"\na=0:1\n\n,b=1:2,",
+		}
+
+		for _, input := range inputs {
+			checkDocument(t, decodeDocument(t, input), input)
+		}
+
+		doc := decodeDocument(t, bootConfig)
+		if len(doc.Tokens) != 7 {
+			t.Fatalf("expected 7 tokens but got %d", len(doc.Tokens))
+		}
+
+		token, ok := doc.Get("LOGLEVEL")
+		if !ok || string(token.Value) != "2" {
+			t.Errorf("expected the last LOGLEVEL but got %v", token)
+		}
+
+		if token, ok := doc.Get("UNKNOWN"); !ok || token.Type != ldf.ValueTypeUnknown11 {
+			t.Errorf("expected UNKNOWN to keep its value type but got %v", token)
+		}
+
+		if _, ok := doc.Get("MISSING"); ok {
+			t.Error("expected MISSING to not be found")
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		doc := decodeDocument(t, bootConfig)
+
+		if err := doc.Set("PATCHSERVERIP", "127.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := doc.Set("AUTHSERVERIP", "127.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := doc.Set("LOGLEVEL", ldf.RawValue{Type: ldf.ValueTypeU32, Value: []byte("3")}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := doc.Set("CRASHLOGS", int32(0)); err != nil {
+			t.Fatal(err)
+		}
+
+		if !doc.Delete("SERVERNAME") || doc.Delete("SERVERNAME") {
+			t.Error("expected SERVERNAME to be deleted once")
+		}
+
+		if err := doc.Set("INVALID", struct{}{}); err == nil {
+			t.Error("expected invalid value error")
+		}
+
+		checkDocument(t, doc, "PATCHSERVERIP=0:127.0.0.1,\r\n"+
+			"  AUTHSERVERIP=0:127.0.0.1 ,\r\n"+
+			"\r\n"+
+			"LOGLEVEL=1:1,\r\n"+
+			"UNKNOWN=11:?,\r\n"+
+			"LOGLEVEL=5:3,\r\n"+
+			"PATCHSERVERPORT=1:80,\r\n"+
+			"CRASHLOGS=1:0\r\n")
+
+		doc.Tokens[0].Value = []byte("localhost")
+		doc.Delim = "\n"
+		if err := doc.Set("LAST", true); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ldf.MarshalText(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(data, []byte("PATCHSERVERIP=0:localhost,\r\n")) || !bytes.HasSuffix(data, []byte("CRASHLOGS=1:0\nLAST=7:1\r\n")) {
+			t.Errorf("unexpected document: %q", data)
+		}
+	})
+
+	t.Run("new", func(t *testing.T) {
+		doc := &ldf.Document{}
+		for i, key := range []string{"a", "b", "c"} {
+			if err := doc.Set(key, int32(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		doc.Delete("b")
+
+		checkDocument(t, doc, "a=1:0,c=1:2")
+
+		data, err := ldf.MarshalBinary(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := &ldf.Document{}
+		if err := ldf.UnmarshalBinary(data, actual); err != nil {
+			t.Fatal(err)
+		}
+		checkDocument(t, actual, "a=1:0,c=1:2")
+	})
+
+	t.Run("lax", func(t *testing.T) {
+		input := "a=0:1\nINVALID\nb=1:2\n"
+
+		decoder := ldf.NewTextDecoder(strings.NewReader(input))
+		decoder.UseLax()
+
+		doc := &ldf.Document{}
+		if err := decoder.Decode(doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(decoder.Errors()) != 1 {
+			t.Errorf("expected 1 error but got: %v", decoder.Errors())
+		}
+
+		if err := doc.Set("b", int32(3)); err != nil {
+			t.Fatal(err)
+		}
+		checkDocument(t, doc, "a=0:1\nINVALID\nb=1:3\n")
+	})
+
+	t.Run("escapes", func(t *testing.T) {
+		input := `path=0:C:\\Program Files\\LEGO Universe\,,name=0:a\,b`
+
+		decoder := ldf.NewTextDecoder(strings.NewReader(input))
+		decoder.UseEscapes()
+
+		doc := &ldf.Document{}
+		if err := decoder.Decode(doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if token, _ := doc.Get("name"); string(token.Value) != "a,b" {
+			t.Errorf("expected a,b but got %q", token.Value)
+		}
+
+		if err := doc.Set("name", "c,d"); err != nil {
+			t.Fatal(err)
+		}
+		checkDocument(t, doc, `path=0:C:\\Program Files\\LEGO Universe\,,name=0:c\,d`)
+	})
+}
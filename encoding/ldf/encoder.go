@@ -7,6 +7,8 @@ import (
 	"io"
 	"reflect"
 	"strconv"
+	"strings"
+	"unicode"
 	"unicode/utf16"
 )
 
@@ -16,12 +18,30 @@ type Entry struct {
 	Value any
 }
 
+// Marshaler is the interface implemented by types that
+// can encode themselves as a single LDF value. Types spread
+// over several keys, e.g. a position stored as "position_x",
+// "position_y" and "position_z", should be nested structs
+// instead. See [MarshalText].
+type Marshaler interface {
+	MarshalLDF() (ValueType, []byte, error)
+}
+
+// Implemented by [TextEncoder] and [BinaryEncoder] to write
+// each encoded key-value pair. The value is always provided
+// in its textual form.
+type tokenWriter interface {
+	write(key string, valueType ValueType, value string) error
+}
+
 type TextEncoder struct {
 	w   io.Writer
 	buf []byte
 
 	delim     string
 	wroteLine bool
+
+	escape bool
 }
 
 func NewTextEncoder(w io.Writer, delim ...string) *TextEncoder {
@@ -29,7 +49,18 @@ func NewTextEncoder(w io.Writer, delim ...string) *TextEncoder {
 	if len(delim) > 0 {
 		d = delim[0]
 	}
-	return &TextEncoder{w, []byte{}, d, false}
+	return &TextEncoder{w, []byte{}, d, false, false}
+}
+
+// Escapes delimiter characters within keys and values. See [Escape].
+//
+// Escaped text is NOT an LDF format the client reads: the client
+// does not unescape values. Escaping should only be used for data
+// read by a [TextDecoder] which also calls [TextDecoder.UseEscapes].
+// Without escapes, keys and values containing a delimiter return a
+// wrapped [ErrDelimiter] error.
+func (e *TextEncoder) UseEscapes() {
+	e.escape = true
 }
 
 func (e *TextEncoder) Reset(w io.Writer) {
@@ -43,29 +74,125 @@ func (e *TextEncoder) write(key string, valueType ValueType, value string) error
 	if e.wroteLine {
 		buf = append(buf, e.delim...)
 	}
-	buf = append(buf, key...)
+	buf, err := appendToken(buf, key, valueType, value, e.delim, e.escape)
+	if err != nil {
+		return err
+	}
+	e.buf = buf
+
+	if _, err := e.w.Write(buf); err != nil {
+		return err
+	}
+
+	e.wroteLine = true
+	return nil
+}
+
+// Returns a wrapped [ErrDelimiter] error if s contains a character
+// a [TextDecoder] splits tokens on, a non-whitespace character of
+// delim, or, within keys, an "=".
+func checkDelims(s string, delim string, key bool) error {
+	chars := ",\r\n" + strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, delim)
+	if key {
+		chars += "="
+	}
+
+	if i := strings.IndexAny(s, chars); i >= 0 {
+		return fmt.Errorf("%q: %w: %q", s, ErrDelimiter, s[i])
+	}
+	return nil
+}
+
+func appendToken(buf []byte, key string, valueType ValueType, value string, delim string, escape bool) ([]byte, error) {
+	if !escape {
+		if err := checkDelims(key, delim, true); err != nil {
+			return nil, fmt.Errorf("key: %w", err)
+		}
+
+		if err := checkDelims(value, delim, false); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if escape {
+		buf = appendEscaped(buf, key, "=")
+	} else {
+		buf = append(buf, key...)
+	}
 	buf = append(buf, '=')
 	buf = strconv.AppendInt(buf, int64(valueType), 10)
 	buf = append(buf, ':')
-	buf = append(buf, value...)
+	if escape {
+		buf = appendEscaped(buf, value, "")
+	} else {
+		buf = append(buf, value...)
+	}
+	return buf, nil
+}
+
+// Writes the document's tokens, keeping the original text
+// of unmodified tokens. See [Document].
+func (e *TextEncoder) encodeDocument(doc *Document) error {
+	buf := e.buf[:0]
+	if e.wroteLine && len(doc.Tokens) > 0 {
+		buf = append(buf, e.delim...)
+	}
+	buf, err := doc.appendText(buf, e.escape)
+	if err != nil {
+		return err
+	}
 	e.buf = buf
 
 	if _, err := e.w.Write(buf); err != nil {
 		return err
 	}
 
-	e.wroteLine = true
+	e.wroteLine = e.wroteLine || len(doc.Tokens) > 0
+	return nil
+}
+
+// Writes a single token. The token's value is written as-is, so
+// tokens read by a [TextDecoder] can be forwarded unchanged, unless
+// it contains a delimiter. See [TextEncoder.UseEscapes].
+func (e *TextEncoder) WriteToken(token Token) error {
+	if err := e.write(token.Key, token.Type, string(token.Value)); err != nil {
+		return fmt.Errorf("ldf: encode: %w", err)
+	}
+	return nil
+}
+
+// Writes a single entry. The entry's value is encoded
+// following the rules of [MarshalText].
+func (e *TextEncoder) WriteEntry(entry Entry) error {
+	if err := encodeKeyValue(e, entry); err != nil {
+		return fmt.Errorf("ldf: encode: %w", err)
+	}
 	return nil
 }
 
-func (e TextEncoder) encodeAny(v any) (ValueType, string, error) {
+func encodeAny(v any) (ValueType, string, error) {
 	switch val := v.(type) {
+	case Marshaler:
+		valueType, data, err := val.MarshalLDF()
+		if err != nil {
+			return 0, "", err
+		}
+		return valueType, string(data), nil
 	case encoding.TextMarshaler:
 		data, err := val.MarshalText()
 		if err != nil {
 			return 0, "", err
 		}
 		return ValueTypeString, string(data), nil
+	case RawValue:
+		return val.Type, string(val.Value), nil
+	case ObjectID:
+		return ValueTypeObjectID, strconv.FormatInt(int64(val), 10), nil
 	case string:
 		return ValueTypeString, val, nil
 	case String16:
@@ -101,7 +228,18 @@ func (e TextEncoder) encodeAny(v any) (ValueType, string, error) {
 	}
 }
 
-func (e TextEncoder) encodeValue(value reflect.Value) (ValueType, string, error) {
+func encodeValue(value reflect.Value) (ValueType, string, error) {
+	// Pointer receivers also implement the value receiver's methods.
+	if value.Kind() != reflect.Pointer && implementsMarshaler(reflect.PointerTo(value.Type())) {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		value = ptr
+	}
+
+	if marshaler, ok := value.Interface().(Marshaler); ok {
+		return encodeAny(marshaler)
+	}
+
 	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
 		data, err := marshaler.MarshalText()
 		if err != nil {
@@ -110,31 +248,36 @@ func (e TextEncoder) encodeValue(value reflect.Value) (ValueType, string, error)
 		return ValueTypeString, string(data), nil
 	}
 
-	if s, ok := value.Interface().(String16); ok {
-		return ValueTypeString, s.String(), nil
+	switch v := value.Interface().(type) {
+	case String16:
+		return ValueTypeString, v.String(), nil
+	case RawValue:
+		return encodeAny(v)
 	}
 
 	switch value.Kind() {
 	case reflect.String:
-		return e.encodeAny(value.String())
+		return encodeAny(value.String())
 	case reflect.Int32:
-		return e.encodeAny(int32(value.Int()))
+		return encodeAny(int32(value.Int()))
 	case reflect.Float32:
-		return e.encodeAny(float32(value.Float()))
+		return encodeAny(float32(value.Float()))
 	case reflect.Float64:
-		return e.encodeAny(value.Float())
+		return encodeAny(value.Float())
 	case reflect.Uint32:
-		return e.encodeAny(uint32(value.Uint()))
+		return encodeAny(uint32(value.Uint()))
 	case reflect.Bool:
-		return e.encodeAny(value.Bool())
+		return encodeAny(value.Bool())
 	case reflect.Uint, reflect.Uint64:
-		return e.encodeAny(value.Uint())
+		return encodeAny(value.Uint())
 	case reflect.Int, reflect.Int64:
-		return e.encodeAny(value.Int())
+		return encodeAny(value.Int())
 	case reflect.Slice:
-		elemKind := value.Type().Elem().Kind()
-		if elemKind == reflect.Uint16 || elemKind == reflect.Uint8 {
-			return e.encodeAny(value.Interface())
+		switch value.Type().Elem().Kind() {
+		case reflect.Uint16:
+			return encodeAny(value.Convert(reflect.TypeFor[[]uint16]()).Interface())
+		case reflect.Uint8:
+			return encodeAny(value.Bytes())
 		}
 		fallthrough
 	default:
@@ -142,52 +285,65 @@ func (e TextEncoder) encodeValue(value reflect.Value) (ValueType, string, error)
 	}
 }
 
-func (e *TextEncoder) encodeKeyValue(entry Entry) error {
-	valueType, value, err := e.encodeAny(entry.Value)
+func encodeKeyValue(w tokenWriter, entry Entry) error {
+	valueType, value, err := encodeAny(entry.Value)
 	if err != nil {
 		return err
 	}
-	return e.write(entry.Key, valueType, value)
+	return w.write(entry.Key, valueType, value)
 }
 
-func (e *TextEncoder) encodeMapAny(m Map) error {
+func encodeMapAny(w tokenWriter, m Map) error {
 	for k, v := range m {
-		valueType, value, err := e.encodeAny(v)
+		valueType, value, err := encodeAny(v)
 		if err != nil {
 			return err
 		}
 
-		if err := e.write(k, valueType, value); err != nil {
+		if err := w.write(k, valueType, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeTokens(w tokenWriter, tokens []Token) error {
+	for _, token := range tokens {
+		if err := w.write(token.Key, token.Type, string(token.Value)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (e *TextEncoder) encode(v any) error {
+func encode(w tokenWriter, v any) error {
 	if v == nil {
 		return nil
 	}
 
 	switch val := v.(type) {
 	case Entry:
-		return e.encodeKeyValue(val)
+		return encodeKeyValue(w, val)
 	case []Entry:
 		for _, kv := range val {
-			if err := e.encodeKeyValue(kv); err != nil {
+			if err := encodeKeyValue(w, kv); err != nil {
 				return err
 			}
 		}
 		return nil
 	case Map:
-		return e.encodeMapAny(val)
+		return encodeMapAny(w, val)
+	case Document:
+		return encodeTokens(w, val.Tokens)
+	case *Document:
+		return encodeTokens(w, val.Tokens)
 	}
 
 	value := reflect.Indirect(reflect.ValueOf(v))
 	switch value.Kind() {
 	case reflect.Struct, reflect.Map:
-		marshal := getArshaler(value.Type()).textMarshal
-		if err := marshal(e, value); err != nil {
+		marshal := getArshaler(value.Type()).marshal
+		if err := marshal(w, value); err != nil {
 			return err
 		}
 	default:
@@ -198,8 +354,18 @@ func (e *TextEncoder) encode(v any) error {
 }
 
 func (e *TextEncoder) Encode(v any) error {
-	if err := e.encode(v); err != nil {
-		return fmt.Errorf("ldf: encode: %v", err)
+	var err error
+	switch doc := v.(type) {
+	case Document:
+		err = e.encodeDocument(&doc)
+	case *Document:
+		err = e.encodeDocument(doc)
+	default:
+		err = encode(e, v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("ldf: encode: %w", err)
 	}
 	return nil
 }
@@ -221,24 +387,58 @@ func (e *TextEncoder) Encode(v any) error {
 //     if the value's length is 0.
 //   - raw (string only): indicates that the field should be
 //     encoded as the [ValueType], [ValueTypeUtf8].
+//   - utf16 (string only): indicates that the field should be
+//     encoded as [ValueTypeString], which is UTF-16 in binary LDF.
+//     Equivalent to "type=0".
+//   - type=N: indicates that the field should be encoded as the
+//     [ValueType] N, e.g. "type=9" for a uint64 object ID. The value
+//     must be convertible to N. See below.
 //
 // Strings, [String16], and []uint16s are encoded as [ValueTypeString].
+// [String16]s are never encoded as [ValueTypeUtf8], even with the
+// "raw" option.
 //
 // Fields with type []uint8 (or []byte) are encoded with the
-// value type [ValueTypeUtf8].
+// value type [ValueTypeUtf8], unless the "utf16" option is used.
 //
 // Fields with type int or uint are encoded to [ValueTypeI64] and
-// [ValueTypeU64] respectively.
+// [ValueTypeU64] respectively. [ObjectID]s are encoded as
+// [ValueTypeObjectID].
+//
+// [RawValue]s are encoded with their own [ValueType].
+//
+// The "type=N" option converts integers to any other integer type
+// if the value fits, floats and doubles to each other, and strings
+// to [ValueTypeString] or [ValueTypeUtf8]. Any value can be encoded
+// as one of the undocumented value types.
 //
-// Fields with an interface type will encode the value contained
-// within the interface.
+// Fields with an interface or pointer type will encode the value
+// contained within the interface or pointed to. Nil interfaces and
+// pointers are omitted.
 //
-// Fields that implement the [encoding.TextMarshaler] interface are
-// treated as string types and obey the "raw" option.
+// Fields that implement the [Marshaler] interface, with either a value
+// or pointer receiver, are encoded with the returned [ValueType] and
+// value. The field's options are still applied. A [Marshaler] is
+// always encoded to the field's key alone; use a nested struct for
+// values which span several keys.
+//
+// Fields that implement the [encoding.TextMarshaler] interface, with
+// either a value or pointer receiver, are treated as string types and
+// obey the "raw" option.
 //
 // Embedded structs are encoded as if their fields were at the same
 // level as their parent struct. Field tags on embedded structs are
-// ignored.
+// ignored. Nested struct fields are flattened the same way, but their
+// keys are prefixed with the field's name, if one is given in the tag:
+//
+//	// Encodes: "spawner_name=0:"
+//	Spawner struct {
+//		Name string `ldf:"name"`
+//	} `ldf:"spawner_"`
+//
+// Slices, other than []uint8 and []uint16, are encoded as indexed keys
+// starting at 0, e.g. a field named "template" is encoded as "template0",
+// "template1", etc. Each element obeys the field's options.
 //
 // Example fields:
 //
@@ -254,11 +454,22 @@ func (e *TextEncoder) Encode(v any) error {
 //	// Encodes: "Field=13:"
 //	Field string `ldf:",raw"`
 //
+//	// Encodes: "Field=9:0"
+//	Field uint64 `ldf:",type=9"`
+//
 // Map key types must be a string. Map values follow the same encoding
 // rules as struct fields.
 //
 // Maps embedded in a struct will capture the remaining LDF keys
 // not mapped to a struct field.
+//
+// [Document]s are encoded token by token, keeping the original
+// text and delimiters of unmodified tokens.
+//
+// Keys and values are written as-is, like the client expects. The
+// client cannot read keys or values containing a delimiter, so they
+// return a wrapped [ErrDelimiter] error. To write them in a format
+// only a [TextDecoder] reads, see [TextEncoder.UseEscapes].
 func MarshalText(v any) ([]byte, error) {
 	buf := bytes.Buffer{}
 	if err := NewTextEncoder(&buf).Encode(v); err != nil {
@@ -2,6 +2,7 @@ package ldf_test
 
 import (
 	"bytes"
+	"math"
 	"slices"
 	"strings"
 	"testing"
@@ -94,6 +95,114 @@ func TestEncode(t *testing.T) {
 		checkExpected(t, []byte("int_list=0:38;24;93;70;37"), actual)
 	})
 
+	t.Run("nested", func(t *testing.T) {
+		actual, err := ldf.MarshalText(newSpawner())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkExpected(t, []byte(`a=9:3,spawner_name=0:spawner,spawner_max_per_node=1:2,spawner_active_on_load=7:1,pos_x=3:1,pos_y=3:-2,spawntemplate0=1:10,spawntemplate1=1:11,script=0:scripts\ai\L_SPAWNER.lua,extra=1:5,marshaler=0:<m>`), actual)
+
+		actual, err = ldf.MarshalText(Spawner{Node: &Point{X: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkExpected(t, []byte("spawner_name=0:,spawner_active_on_load=7:0,pos_x=3:0,pos_y=3:0,node_x=3:1,node_y=3:0,marshaler=0:<>"), actual)
+	})
+
+	t.Run("utf16", func(t *testing.T) {
+		v := Utf16Strings{
+			String16: ldf.ToString16("🧱 bricks"),
+			Uint16s:  ldf.ToString16("𝄞"),
+			String:   "string",
+			Bytes:    []byte("bytes"),
+			Raw16:    ldf.ToString16("raw"),
+		}
+
+		actual, err := ldf.MarshalText(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkExpected(t, []byte("string16=0:🧱 bricks,uint16s=0:𝄞,string=0:string,bytes=0:bytes,raw16=0:raw"), actual)
+
+		invalid := struct {
+			A string `ldf:"a,raw,utf16"`
+		}{}
+		if _, err := ldf.MarshalText(invalid); err == nil {
+			t.Error("expected raw and utf16 to be mutually exclusive")
+		}
+	})
+
+	t.Run("value_types", func(t *testing.T) {
+		v := ValueTypes{
+			Object:  1152921504606846994,
+			ID:      1152921504606846995,
+			Small:   42,
+			Float:   1.5,
+			Text:    "text",
+			Unknown: ldf.RawValue{Type: ldf.ValueTypeUnknown10, Value: []byte("unknown")},
+		}
+
+		actual, err := ldf.MarshalText(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkExpected(t, []byte("object=9:1152921504606846994,id=9:1152921504606846995,small=5:42,float=3:1.5,text=13:text,unknown=10:unknown"), actual)
+
+		v.Small = -1
+		if _, err := ldf.MarshalText(v); err == nil {
+			t.Error("expected negative value to not fit type 5")
+		}
+
+		v.ID = math.MaxUint64
+		v.Small = 0
+		if _, err := ldf.MarshalText(v); err == nil {
+			t.Error("expected max uint64 to not fit type 9")
+		}
+
+		invalid := []any{
+			struct {
+				A string `ldf:"a,type=1"`
+			}{},
+			struct {
+				A int `ldf:"a,type=14"`
+			}{},
+		}
+		for _, v := range invalid {
+			if _, err := ldf.MarshalText(v); err == nil {
+				t.Errorf("%T: expected error", v)
+			}
+		}
+	})
+
+	t.Run("marshaler", func(t *testing.T) {
+		v := Marshalers{
+			Faction:   FactionParadox,
+			Color:     Color{255, 128, 0},
+			Highlight: &Color{0, 0, 255},
+			Palette:   []Color{{1, 2, 3}, {4, 5, 6}},
+			Position:  Vector3{1, -2.5, 3},
+			Rotation:  &Vector3{0, 1, 0},
+			ID:        FactionSentinel,
+		}
+
+		data, err := ldf.MarshalText(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkExpected(t, []byte("faction=1:2,color=5:16744448,highlight=5:255,palette0=5:66051,palette1=5:263430,position_x=3:1,position_y=3:-2.5,position_z=3:3,rotation_x=3:0,rotation_y=3:1,rotation_z=3:0,id=9:1"), data)
+
+		data, err = ldf.MarshalText(ldf.Map{"faction": FactionSentinel})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkExpected(t, []byte("faction=1:1"), data)
+	})
+
 	t.Run("map", func(t *testing.T) {
 		v := map[string]any{
 			"String":  "An encoded string",
@@ -174,4 +283,41 @@ func TestEncode(t *testing.T) {
 			t.Errorf("\nexpected = \"%s\"\nactual   = \"%s\"", strings.Join(expected, ","), strings.Join(actual, ","))
 		}
 	})
+
+	t.Run("stream", func(t *testing.T) {
+		input := "SERVERNAME=0:Overbuild Universe (US),\nPATCHSERVERIP=0:localhost,\nUNKNOWN=13:\u00e9,\nLOGLEVEL=1:1,\nPATCHSERVERPORT=1:80"
+
+		buf := bytes.Buffer{}
+		encoder := ldf.NewTextEncoder(&buf, ",\n")
+
+		decoder := ldf.NewTextDecoder(strings.NewReader(input))
+		for decoder.Next() {
+			token := decoder.Token()
+			switch token.Key {
+			case "LOGLEVEL":
+				continue
+			case "PATCHSERVERIP":
+				token.Value = []byte("127.0.0.1")
+			}
+
+			if err := encoder.WriteToken(token); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := decoder.Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := encoder.WriteEntry(ldf.Entry{Key: "CRASHLOG", Value: int32(0)}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := encoder.WriteEntry(ldf.Entry{Key: "INVALID", Value: struct{}{}}); err == nil {
+			t.Error("expected invalid entry error")
+		}
+
+		expected := "SERVERNAME=0:Overbuild Universe (US),\nPATCHSERVERIP=0:127.0.0.1,\nUNKNOWN=13:\u00e9,\nPATCHSERVERPORT=1:80,\nCRASHLOG=1:0"
+		checkExpected(t, []byte(expected), buf.Bytes())
+	})
 }
@@ -0,0 +1,77 @@
+package ldf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned, wrapped, when encoding a value of an undocumented value
+// type in binary. The binary layout of their values is unknown, so
+// they can only be encoded as text.
+var ErrBinaryUnsupported = errors.New("value type has no binary form")
+
+// Returned, wrapped, when encoding a textual key or value containing a
+// delimiter without [TextEncoder.UseEscapes]. The client splits text on
+// its delimiters and has no way of escaping them.
+var ErrDelimiter = errors.New("contains a delimiter")
+
+// A DecodeError describes a token which could not be decoded.
+type DecodeError struct {
+	// The token's key, or empty if the key could not be parsed.
+	Key string
+
+	// The byte offset of the token. For binary LDF, the offset is
+	// relative to the start of the (decompressed) value.
+	Offset int64
+
+	// The 1-based line of the token, or 0 for binary LDF.
+	Line int
+
+	Cause error
+}
+
+func (e *DecodeError) Error() string {
+	s := "ldf: decode: "
+	if e.Line > 0 {
+		s += fmt.Sprintf("line %d, ", e.Line)
+	}
+	s += fmt.Sprintf("offset %d: ", e.Offset)
+
+	if len(e.Key) > 0 {
+		s += e.Key + ": "
+	}
+	return s + e.Cause.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Cause
+}
+
+// The position of a token within its input. See [DecodeError].
+type tokenPosition struct {
+	offset int64
+	line   int
+}
+
+func newDecodeError(key string, pos tokenPosition, err error) *DecodeError {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr
+	}
+
+	return &DecodeError{
+		Key:    key,
+		Offset: pos.offset,
+		Line:   pos.line,
+		Cause:  err,
+	}
+}
+
+// Returns *DecodeErrors as-is, so callers can use [errors.As].
+func wrapDecodeError(err error) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr
+	}
+	return fmt.Errorf("ldf: decode: %w", err)
+}
@@ -0,0 +1,90 @@
+package ldf
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Appends s with each backslash, comma, carriage return, newline,
+// and character within extra preceded by a backslash. Carriage
+// returns and newlines are written as "\r" and "\n" respectively.
+func appendEscaped(buf []byte, s string, extra string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\\', c == ',', strings.IndexByte(extra, c) >= 0:
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// Escape returns s with the delimiter characters of textual
+// LDF escaped by a backslash. The client does not read escaped
+// text, see [TextEncoder.UseEscapes]:
+//
+//   - "\" is escaped as "\\".
+//   - "," is escaped as "\,".
+//   - A carriage return is escaped as "\r".
+//   - A newline is escaped as "\n".
+//   - "=" is escaped as "\=", but only within keys.
+//
+// Escape escapes s as a value.
+func Escape(s string) string {
+	return string(appendEscaped(nil, s, ""))
+}
+
+// Unescape reverses [Escape] for both keys and values. Backslashes
+// not followed by an escaped character are kept as-is.
+func Unescape(s []byte) []byte {
+	if bytes.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf = append(buf, s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case 'r':
+			buf = append(buf, '\r')
+		case 'n':
+			buf = append(buf, '\n')
+		case '\\', ',', '=':
+			buf = append(buf, s[i+1])
+		default:
+			buf = append(buf, '\\', s[i+1])
+		}
+		i++
+	}
+	return buf
+}
+
+// Reports whether the character at data[i] is escaped
+// by an odd number of preceding backslashes.
+func isEscaped(data []byte, i int) bool {
+	n := 0
+	for i--; i >= 0 && data[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// Returns the index of the first sep within data which
+// is not escaped, or -1 if there is none.
+func indexUnescaped(data []byte, sep byte) int {
+	for i := 0; i < len(data); i++ {
+		if data[i] == sep && !isEscaped(data, i) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,109 @@
+package ldf_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/I-Am-Dench/goverbuild/encoding/ldf"
+)
+
+var pathologicalValues = []string{
+	"",
+	"http://example.com/patch?a=1,b=2,c=3",
+	"line one\nline two\r\nline three",
+	`C:\Program Files\LEGO Universe\`,
+	`\\server\share\,`,
+	`\`,
+	`\\`,
+	`\,`,
+	",,,",
+	"\n",
+	"key=0:value,other=1:2",
+	"a=b:c",
+}
+
+func TestEscape(t *testing.T) {
+	for _, value := range pathologicalValues {
+		escaped := ldf.Escape(value)
+		if actual := string(ldf.Unescape([]byte(escaped))); actual != value {
+			t.Errorf("%q: escaped as %q but unescaped as %q", value, escaped, actual)
+		}
+	}
+
+	// Backslashes not followed by an escaped character are kept.
+	for _, value := range []string{`scripts\ai\L_SCRIPT.lua`, `a\b\c`} {
+		if actual := string(ldf.Unescape([]byte(value))); actual != value {
+			t.Errorf("expected %q but got %q", value, actual)
+		}
+	}
+
+	delims := map[string]*regexp.Regexp{
+		",":   nil,
+		",\n": nil,
+		"\n":  regexp.MustCompile("\n"),
+	}
+
+	for delim, pattern := range delims {
+		expected := []ldf.Entry{}
+		for i, value := range pathologicalValues {
+			expected = append(expected, ldf.Entry{Key: value + string(rune('a'+i)), Value: value})
+		}
+		expected = append(expected, ldf.Entry{Key: "=,\n", Value: []byte("=,\n")})
+
+		buf := bytes.Buffer{}
+		encoder := ldf.NewTextEncoder(&buf, delim)
+		encoder.UseEscapes()
+
+		if err := encoder.Encode(expected); err != nil {
+			t.Fatal(err)
+		}
+
+		decoder := ldf.NewTextDecoder(&buf)
+		decoder.UseEscapes()
+		if pattern != nil {
+			decoder.SetDelim(pattern)
+		}
+
+		actual := []ldf.Entry{}
+		if err := decoder.Decode(&actual); err != nil {
+			t.Fatalf("%q: %v", delim, err)
+		}
+
+		if !slices.EqualFunc(expected, actual, func(a, b ldf.Entry) bool {
+			if bs, ok := a.Value.([]byte); ok {
+				return a.Key == b.Key && bytes.Equal(bs, b.Value.([]byte))
+			}
+			return a == b
+		}) {
+			t.Errorf("%q:\nexpected = %q\nactual   = %q", delim, expected, actual)
+		}
+	}
+
+	// Without escapes, values are written as-is.
+	data, err := ldf.MarshalText(ldf.Entry{Key: "path", Value: `scripts\ai\L_SCRIPT.lua`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkExpected(t, []byte(`path=0:scripts\ai\L_SCRIPT.lua`), data)
+
+	// Without escapes, delimiters cannot be written as the client reads them.
+	invalid := []ldf.Entry{
+		{Key: "url", Value: "http://example.com/patch?a=1,b=2"},
+		{Key: "description", Value: "line one\nline two"},
+		{Key: "key=", Value: "value"},
+		{Key: "a,b", Value: "value"},
+	}
+	for _, entry := range invalid {
+		if _, err := ldf.MarshalText(entry); !errors.Is(err, ldf.ErrDelimiter) {
+			t.Errorf("%q=%q: expected %v but got %v", entry.Key, entry.Value, ldf.ErrDelimiter, err)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := ldf.NewTextEncoder(&buf, ";").Encode(ldf.Entry{Key: "a", Value: "b;c"}); !errors.Is(err, ldf.ErrDelimiter) {
+		t.Errorf("expected %v for custom delimiter but got %v", ldf.ErrDelimiter, err)
+	}
+}
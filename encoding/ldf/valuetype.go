@@ -2,26 +2,34 @@ package ldf
 
 import (
 	"fmt"
+	"strconv"
 	"unicode/utf16"
 )
 
 type ValueType int
 
+// The value types 2, 6, 10, 11, and 12 are undocumented. Their
+// values are decoded as [RawValue]s, which preserve the value's
+// textual form. Their binary size is unknown, so encoding them in
+// binary returns [ErrBinaryUnsupported], and decoding them in binary
+// keeps the rest of the value as raw bytes. See [BinaryDecoder].
 const (
 	ValueTypeString = ValueType(iota) // An encoding-independent string (could be utf-8 or utf-16 depending on usage).
 	ValueTypeI32
-	_
+	ValueTypeUnknown2
 	ValueTypeFloat
 	ValueTypeDouble
 	ValueTypeU32
-	_
+	ValueTypeUnknown6
 	ValueTypeBool
 	ValueTypeU64
-	ValueTypeI64
-	_
-	_
-	_
+	ValueTypeI64 // Also used for LWOOBJIDs. See [ObjectID].
+	ValueTypeUnknown10
+	ValueTypeUnknown11
+	ValueTypeUnknown12
 	ValueTypeUtf8 // A string intended to always be encoded in utf-8.
+
+	ValueTypeObjectID = ValueTypeI64
 )
 
 func (t ValueType) String() string {
@@ -44,11 +52,67 @@ func (t ValueType) String() string {
 		return "Signed64"
 	case ValueTypeUtf8:
 		return "Utf8"
+	case ValueTypeUnknown2, ValueTypeUnknown6, ValueTypeUnknown10, ValueTypeUnknown11, ValueTypeUnknown12:
+		return fmt.Sprintf("Unknown%d", int(t))
 	default:
 		return fmt.Sprintf("ValueType(%d)", t)
 	}
 }
 
+func (t ValueType) isUnknown() bool {
+	switch t {
+	case ValueTypeUnknown2, ValueTypeUnknown6, ValueTypeUnknown10, ValueTypeUnknown11, ValueTypeUnknown12:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t ValueType) isInteger() bool {
+	return t == ValueTypeI32 || t == ValueTypeU32 || t == ValueTypeU64 || t == ValueTypeI64
+}
+
+// Converts the textual value from one value type to another. Integers
+// can be converted to other integer types if the value fits, floats
+// and doubles can be converted to each other, and [ValueTypeString]
+// and [ValueTypeUtf8] can be converted to each other. Any value can
+// be converted to an undocumented value type.
+func convertValue(value string, from, to ValueType) (string, error) {
+	switch {
+	case from == to, to.isUnknown():
+		return value, nil
+	case from.isInteger() && to.isInteger():
+		if _, err := (Token{Type: to, Value: []byte(value)}).Interface(); err != nil {
+			return "", err
+		}
+		return value, nil
+	case from == ValueTypeDouble && to == ValueTypeFloat:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(v, 'g', -1, 32), nil
+	case from == ValueTypeFloat && to == ValueTypeDouble:
+		return value, nil
+	case (from == ValueTypeString || from == ValueTypeUtf8) && (to == ValueTypeString || to == ValueTypeUtf8):
+		return value, nil
+	default:
+		return "", fmt.Errorf("cannot encode %v as %v", from, to)
+	}
+}
+
+// An LWOOBJID, the ID of an object in the game world.
+// ObjectIDs are encoded as [ValueTypeObjectID].
+type ObjectID int64
+
+// A value kept in its textual form, regardless of its type. RawValue
+// can hold values of the undocumented value types and is encoded with
+// its own Type.
+type RawValue struct {
+	Type  ValueType
+	Value []byte
+}
+
 type String16 []uint16
 
 func ToString16(s string) String16 {
@@ -4,4 +4,10 @@ go 1.23.0
 
 require github.com/snksoft/crc v1.1.0
 
-require github.com/mattn/go-sqlite3 v1.14.33
+require (
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/lib/pq v1.9.0
+	github.com/mattn/go-sqlite3 v1.14.33
+)
+
+require filippo.io/edwards25519 v1.1.0 // indirect